@@ -3,8 +3,10 @@ module go.albinodrought.com/creamy-prediction-market
 go 1.25.1
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/sirupsen/logrus v1.9.4 // indirect
-	golang.org/x/crypto v0.47.0 // indirect
-	golang.org/x/sys v0.40.0 // indirect
+	github.com/google/uuid v1.6.0
+	github.com/sirupsen/logrus v1.9.4
+	golang.org/x/crypto v0.47.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require golang.org/x/sys v0.40.0 // indirect
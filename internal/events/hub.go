@@ -9,8 +9,16 @@ import (
 // Event types
 const (
 	EventPredictions         = "predictions"          // Predictions list changed
+	EventDeadlineExtended    = "deadline_extended"    // A late bet pushed back a prediction's closes_at
+	EventClosingSoon         = "closing_soon"         // A prediction the user hasn't bet on is about to close (for specific user)
+	EventReveal              = "reveal"               // A sealed prediction closed and its pool can now be seen
 	EventLeaderboard         = "leaderboard"          // Leaderboard changed (tokens changed)
 	EventBets                = "bets"                 // User's bets changed (for specific user)
+	EventParlays             = "parlays"              // User's parlays changed (for specific user)
+	EventProposals           = "proposals"            // A proposal was submitted or resolved
+	EventDisputes            = "disputes"             // A dispute was filed or resolved
+	EventFrozen              = "frozen"               // An admin froze the market, betting is paused
+	EventThawed              = "thawed"               // The market was thawed, betting is back on
 	EventAchievement         = "achievement"          // User earned an achievement (for specific user)
 	EventGlobalAction        = "global_action"        // A user triggered a global cosmetic effect
 	EventMinigameLeaderboard = "minigame_leaderboard" // Minigame high scores changed
@@ -23,6 +31,11 @@ type Event struct {
 	AchievementID string `json:"achievement_id,omitempty"` // Optional: for achievement events
 	ActionType    string `json:"action_type,omitempty"`    // Optional: for global_action events
 	ActorName     string `json:"actor_name,omitempty"`     // Optional: for global_action events
+	PredictionID  string `json:"prediction_id,omitempty"`  // Optional: for prediction-specific events
+	// Optional: for reveal events covering several predictions at once
+	PredictionIDs []string `json:"prediction_ids,omitempty"`
+	ClosesAt      string   `json:"closes_at,omitempty"`    // Optional: for deadline_extended and closing_soon events
+	SecondsLeft   int64    `json:"seconds_left,omitempty"` // Optional: for closing_soon events
 }
 
 // Client represents a connected SSE client
@@ -110,6 +123,36 @@ func (h *Hub) EmitPredictions() {
 	h.Emit(Event{Type: EventPredictions})
 }
 
+// EmitDeadlineExtended notifies all clients that a prediction's closes_at moved
+func (h *Hub) EmitDeadlineExtended(predictionID, closesAt string) {
+	h.Emit(Event{Type: EventDeadlineExtended, PredictionID: predictionID, ClosesAt: closesAt})
+}
+
+// EmitClosingSoon warns a specific user that a prediction is about to close
+func (h *Hub) EmitClosingSoon(userID, predictionID, closesAt string, secondsLeft int64) {
+	h.Emit(Event{Type: EventClosingSoon, UserID: userID, PredictionID: predictionID, ClosesAt: closesAt, SecondsLeft: secondsLeft})
+}
+
+// EmitReveal notifies all clients that a sealed prediction's pool has been revealed
+func (h *Hub) EmitReveal(predictionID string) {
+	h.Emit(Event{Type: EventReveal, PredictionID: predictionID})
+}
+
+// EmitReveals notifies all clients that several sealed predictions' pools have been revealed
+func (h *Hub) EmitReveals(predictionIDs []string) {
+	h.Emit(Event{Type: EventReveal, PredictionIDs: predictionIDs})
+}
+
+// EmitFrozen notifies all clients that the market has been frozen
+func (h *Hub) EmitFrozen() {
+	h.Emit(Event{Type: EventFrozen})
+}
+
+// EmitThawed notifies all clients that the market has been thawed
+func (h *Hub) EmitThawed() {
+	h.Emit(Event{Type: EventThawed})
+}
+
 // EmitLeaderboard notifies all clients that leaderboard changed
 func (h *Hub) EmitLeaderboard() {
 	h.Emit(Event{Type: EventLeaderboard})
@@ -124,6 +167,25 @@ func (h *Hub) EmitBetsAll() {
 	h.Emit(Event{Type: EventBets})
 }
 
+// EmitParlays notifies a specific user that their parlays changed
+func (h *Hub) EmitParlays(userID string) {
+	h.Emit(Event{Type: EventParlays, UserID: userID})
+}
+
+func (h *Hub) EmitParlaysAll() {
+	h.Emit(Event{Type: EventParlays})
+}
+
+// EmitProposals notifies all clients that proposals changed. Clients only see their own proposals unless they're admins.
+func (h *Hub) EmitProposals() {
+	h.Emit(Event{Type: EventProposals})
+}
+
+// EmitDisputes notifies all clients that disputes changed
+func (h *Hub) EmitDisputes() {
+	h.Emit(Event{Type: EventDisputes})
+}
+
 // EmitAchievement notifies a specific user that they earned an achievement
 func (h *Hub) EmitAchievement(userID, achievementID string) {
 	h.Emit(Event{Type: EventAchievement, UserID: userID, AchievementID: achievementID})
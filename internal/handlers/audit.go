@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/repo"
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// Audit log: requireAdmin records every successful non-GET admin request, with a before/after diff of its target.
+
+const maxAuditBodyBytes = 64 << 10
+
+// readOnlyAdminRoutes aren't GETs but don't change anything, so they aren't audited and don't wait for changeMu.
+// Testing an oracle fetches its endpoint, which mustn't hold up other changes.
+var readOnlyAdminRoutes = map[string]struct{}{
+	"POST /api/admin/predictions/{id}/oracle/test": {},
+}
+
+type auditContextKey struct{}
+
+// auditRecording collects what a handler wants to add to its audit entry.
+type auditRecording struct {
+	targetType  string
+	affectedIDs []string
+	// befores are snapshots of things a bulk action is about to change, by ID
+	befores map[string]map[string]any
+}
+
+// auditBefore snapshots something a bulk action is about to change, so its entry can show what changed.
+// Call it before changing the thing, then auditAffected once it has changed.
+func (h *Handler) auditBefore(r *http.Request, id string) {
+	recording, ok := r.Context().Value(auditContextKey{}).(*auditRecording)
+	if !ok {
+		return
+	}
+	if _, ok := recording.befores[id]; !ok {
+		recording.befores[id] = h.auditSnapshot(recording.targetType, id)
+	}
+}
+
+// auditAffected notes other things a bulk action changed, for its audit entry.
+func auditAffected(r *http.Request, ids ...string) {
+	if recording, ok := r.Context().Value(auditContextKey{}).(*auditRecording); ok {
+		recording.affectedIDs = append(recording.affectedIDs, ids...)
+	}
+}
+
+// auditResponseWriter remembers the status and the start of the body, so created IDs can be found.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *auditResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if room := maxAuditBodyBytes - w.body.Len(); room > 0 {
+		w.body.Write(b[:min(len(b), room)])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// auditTargetType picks what kind of thing an admin route acts on from its pattern
+// (ex: "POST /api/admin/predictions/{id}/close" is "predictions").
+func auditTargetType(pattern string) string {
+	_, path, _ := strings.Cut(pattern, " ")
+	rest, ok := strings.CutPrefix(path, "/api/admin/")
+	if !ok {
+		return ""
+	}
+	if strings.HasSuffix(rest, "/oracle") {
+		return "oracles"
+	}
+	targetType, _, _ := strings.Cut(rest, "/")
+	return targetType
+}
+
+// auditSnapshot returns the target as a map of its JSON fields, or nil if it doesn't exist (yet).
+func (h *Handler) auditSnapshot(targetType, id string) map[string]any {
+	if id == "" {
+		return nil
+	}
+
+	var target any
+	var err error
+	switch targetType {
+	case "predictions":
+		target, err = h.Store.GetPrediction(id)
+	case "users":
+		target, err = h.Store.GetUser(id)
+	case "occasions":
+		target, err = h.Store.GetOccasion(id)
+	case "templates":
+		target, err = h.Store.GetTemplate(id)
+	case "proposals":
+		target, err = h.Store.GetProposal(id)
+	case "disputes":
+		target, err = h.Store.GetDispute(id)
+	case "oracles":
+		target, err = h.Store.GetOracle(id)
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+
+	encoded, err := json.Marshal(target)
+	if err != nil {
+		return nil
+	}
+	var snapshot map[string]any
+	if err := json.Unmarshal(encoded, &snapshot); err != nil {
+		return nil
+	}
+
+	// keep secrets out of the log, but still show that they changed
+	for _, key := range []string{"pin_hash", "secret"} {
+		if value, ok := snapshot[key].(string); ok && value != "" {
+			sum := sha256.Sum256([]byte(value))
+			snapshot[key] = "redacted:" + hex.EncodeToString(sum[:4])
+		}
+	}
+	return snapshot
+}
+
+func auditDiff(before, after map[string]any) map[string]types.AuditChange {
+	changes := map[string]types.AuditChange{}
+	for key, value := range before {
+		if !reflect.DeepEqual(value, after[key]) {
+			changes[key] = types.AuditChange{Before: value, After: after[key]}
+		}
+	}
+	for key, value := range after {
+		if _, ok := before[key]; !ok && value != nil {
+			changes[key] = types.AuditChange{Before: nil, After: value}
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// audit wraps an admin handler, recording an audit entry if it changed something.
+// Changes are made one at a time under changeMu, so a diff never picks up someone else's change.
+func (h *Handler) audit(admin types.User, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next(w, r)
+			return
+		}
+		if _, ok := readOnlyAdminRoutes[r.Pattern]; ok {
+			next(w, r)
+			return
+		}
+
+		h.changeMu.Lock()
+		defer h.changeMu.Unlock()
+
+		targetType := auditTargetType(r.Pattern)
+		targetID := r.PathValue("id")
+		before := h.auditSnapshot(targetType, targetID)
+
+		var request any
+		if targetID == "" && targetType != "users" {
+			// nothing to diff, so keep what was asked for instead
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxAuditBodyBytes+1))
+			if err != nil {
+				h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+				return
+			}
+			if len(body) <= maxAuditBodyBytes {
+				var decoded any
+				if json.Unmarshal(body, &decoded) == nil {
+					request = decoded
+				}
+			}
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		}
+
+		recording := &auditRecording{targetType: targetType, befores: map[string]map[string]any{}}
+		r = r.WithContext(context.WithValue(r.Context(), auditContextKey{}, recording))
+		recorder := &auditResponseWriter{ResponseWriter: w}
+
+		next(recorder, r)
+
+		if recorder.status == 0 {
+			recorder.status = http.StatusOK
+		}
+		if recorder.status >= 400 {
+			return
+		}
+
+		var created struct {
+			ID string `json:"id"`
+		}
+		_ = json.Unmarshal(recorder.body.Bytes(), &created)
+
+		entryID, err := repo.NewID()
+		if err != nil {
+			h.Logger.WithError(err).Error("failed to generate audit entry ID")
+			return
+		}
+		entry := types.AuditEntry{
+			ID:          entryID,
+			CreatedAt:   time.Now().Format(time.RFC3339),
+			ActorUserID: admin.ID,
+			ActorName:   admin.Name,
+			Action:      r.Pattern,
+			Status:      recorder.status,
+			TargetType:  targetType,
+			TargetID:    targetID,
+			AffectedIDs: recording.affectedIDs,
+			Request:     request,
+		}
+		if targetID == "" {
+			entry.TargetID = created.ID
+		} else if created.ID != "" && created.ID != targetID {
+			entry.ResultID = created.ID
+		}
+		entry.Changes = auditDiff(before, h.auditSnapshot(targetType, entry.TargetID))
+		for _, id := range recording.affectedIDs {
+			changes := auditDiff(recording.befores[id], h.auditSnapshot(targetType, id))
+			if changes == nil {
+				continue
+			}
+			if entry.AffectedChanges == nil {
+				entry.AffectedChanges = map[string]map[string]types.AuditChange{}
+			}
+			entry.AffectedChanges[id] = changes
+		}
+
+		h.Store.AddAuditEntry(entry)
+	}
+}
+
+// ListAuditEntries serves the audit log, newest first. Filter with ?actor= (user ID or name), ?action= (part of the route),
+// ?target_type=, ?target_id= (also matches created and affected IDs), ?since= and ?until=.
+func (h *Handler) ListAuditEntries(w http.ResponseWriter, r *http.Request) {
+	tr, err := parseTimeRange(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid since or until")
+		return
+	}
+
+	query := r.URL.Query()
+	actor := query.Get("actor")
+	action := query.Get("action")
+	targetType := query.Get("target_type")
+	targetID := query.Get("target_id")
+
+	entries := []types.AuditEntry{}
+	for _, e := range h.Store.ListAuditEntries() {
+		if actor != "" && e.ActorUserID != actor && !strings.EqualFold(e.ActorName, actor) {
+			continue
+		}
+		if action != "" && !strings.Contains(e.Action, action) {
+			continue
+		}
+		if targetType != "" && e.TargetType != targetType {
+			continue
+		}
+		if targetID != "" && e.TargetID != targetID && e.ResultID != targetID && !containsString(e.AffectedIDs, targetID) {
+			continue
+		}
+		if !tr.contains(e.CreatedAt) {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID > entries[j].ID // UUIDv7, so newest first
+	})
+
+	writePage(h, w, r, entries, newestFirst(func(e types.AuditEntry) string { return e.ID }))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/repo"
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// Batch close, reopen, void or decide, for ending a whole segment of the night at once.
+
+type BatchAction string
+
+const (
+	BatchActionClose  = BatchAction("close")
+	BatchActionReopen = BatchAction("reopen")
+	BatchActionVoid   = BatchAction("void")
+	BatchActionDecide = BatchAction("decide")
+)
+
+const (
+	batchResultOK                  = "ok"
+	batchResultPendingConfirmation = "pending_confirmation"
+	batchResultError               = "error"
+)
+
+type BatchPredictionsRequest struct {
+	Action BatchAction `json:"action"`
+	// PredictionIDs or Tag picks the predictions. A tag only picks predictions the action applies to (and that the
+	// admin's roles allow it on).
+	PredictionIDs []string `json:"prediction_ids"`
+	Tag           string   `json:"tag"`
+
+	// WinningChoiceIDs maps prediction IDs to their winning choice, for decide
+	WinningChoiceIDs map[string]string `json:"winning_choice_ids"`
+	// WinningChoiceName decides predictions without an entry in WinningChoiceIDs with the choice of this name (ex: "Yes")
+	WinningChoiceName string `json:"winning_choice_name"`
+}
+
+type BatchPredictionResult struct {
+	PredictionID string `json:"prediction_id"`
+	Status       string `json:"status"`
+	Error        string `json:"error,omitempty"`
+}
+
+// permission is what the action needs for each prediction, as roles can do some actions but not others.
+func (a BatchAction) permission() types.Permission {
+	if a == BatchActionVoid || a == BatchActionDecide {
+		return types.PermissionDecidePredictions
+	}
+	return types.PermissionManagePredictions
+}
+
+// batchActionApplies returns true if a prediction picked by tag is in a state the action makes sense for.
+func batchActionApplies(action BatchAction, p types.Prediction) bool {
+	switch action {
+	case BatchActionClose:
+		return p.Status == types.PredictionStatusOpen
+	case BatchActionReopen, BatchActionDecide:
+		return p.Status == types.PredictionStatusClosed
+	case BatchActionVoid:
+		return p.Status != types.PredictionStatusDecided && p.Status != types.PredictionStatusVoid
+	}
+	return false
+}
+
+func (h *Handler) BatchPredictions(w http.ResponseWriter, r *http.Request) {
+	admin, _ := h.getAuthenticatedUser(r)
+
+	var req BatchPredictionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	switch req.Action {
+	case BatchActionClose, BatchActionReopen, BatchActionVoid, BatchActionDecide:
+	default:
+		h.errorResponse(w, http.StatusBadRequest, "Action must be close, reopen, void or decide")
+		return
+	}
+	if (len(req.PredictionIDs) == 0) == (req.Tag == "") {
+		h.errorResponse(w, http.StatusBadRequest, "Pick predictions with either prediction_ids or tag")
+		return
+	}
+	if req.Action == BatchActionDecide && len(req.WinningChoiceIDs) == 0 && req.WinningChoiceName == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Decide needs winning_choice_ids or winning_choice_name")
+		return
+	}
+
+	ids := req.PredictionIDs
+	if req.Tag != "" {
+		ids = []string{}
+		for _, p := range h.Store.ListPredictions() {
+			if p.HasTag(req.Tag) && batchActionApplies(req.Action, p) && admin.Can(req.Action.permission(), p.OccasionID) {
+				ids = append(ids, p.ID)
+			}
+		}
+		sort.Strings(ids)
+	}
+
+	results := make([]BatchPredictionResult, 0, len(ids))
+	decided := []string{}
+	voided := false
+	events := &pendingEvents{}
+	seen := map[string]struct{}{}
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		if p, err := h.Store.GetPrediction(id); err == nil && !admin.Can(req.Action.permission(), p.OccasionID) {
+			results = append(results, BatchPredictionResult{PredictionID: id, Status: batchResultError, Error: "You don't have permission to do that to this prediction"})
+			continue
+		}
+
+		h.auditBefore(r, id)
+		status, err := h.applyBatchAction(admin.ID, req, id, events)
+		if err != nil {
+			results = append(results, BatchPredictionResult{PredictionID: id, Status: batchResultError, Error: h.batchErrorMessage(err, id)})
+			continue
+		}
+		results = append(results, BatchPredictionResult{PredictionID: id, Status: status})
+		auditAffected(r, id)
+		events.predictions = true
+		if status != batchResultOK {
+			continue
+		}
+		switch req.Action {
+		case BatchActionDecide:
+			decided = append(decided, id)
+		case BatchActionVoid:
+			voided = true
+		}
+	}
+
+	// one round of events for the whole batch
+	switch {
+	case len(decided) > 0:
+		h.afterPredictionsDecided(decided, events)
+	case voided:
+		h.afterPredictionsVoided(events)
+	default:
+		h.emitEvents(events)
+	}
+
+	h.jsonResponse(w, http.StatusOK, map[string]any{"results": results})
+}
+
+// applyBatchAction applies the batch's action to one prediction, returning its result status.
+// Events are left in events for the batch to send at the end.
+func (h *Handler) applyBatchAction(adminID string, req BatchPredictionsRequest, id string, events *pendingEvents) (string, error) {
+	switch req.Action {
+	case BatchActionClose:
+		prediction, err := h.Store.GetPrediction(id)
+		if err != nil {
+			return "", err
+		}
+		if err := h.Store.ClosePrediction(id); err != nil {
+			return "", err
+		}
+		if prediction.Sealed {
+			events.reveals = append(events.reveals, id)
+		}
+		return batchResultOK, nil
+
+	case BatchActionReopen:
+		return batchResultOK, h.Store.ReopenPrediction(id)
+
+	case BatchActionVoid:
+		if h.needsSecondAdmin(id) {
+			return h.applyOrRequestBatchDecision(adminID, id, types.PendingDecision{Action: types.DecisionActionVoid})
+		}
+		return batchResultOK, h.Store.VoidPrediction(id)
+
+	case BatchActionDecide:
+		choiceID, err := h.batchWinningChoice(req, id)
+		if err != nil {
+			return "", err
+		}
+		if h.needsSecondAdmin(id) {
+			return h.applyOrRequestBatchDecision(adminID, id, types.PendingDecision{Action: types.DecisionActionDecide, WinningChoiceID: choiceID})
+		}
+		return batchResultOK, h.Store.DecidePrediction(id, choiceID, "")
+	}
+	return "", nil
+}
+
+func (h *Handler) applyOrRequestBatchDecision(adminID, id string, d types.PendingDecision) (string, error) {
+	applied, err := h.applyOrRequestDecision(adminID, id, &d)
+	if err != nil {
+		return "", err
+	}
+	if applied {
+		return batchResultOK, nil
+	}
+	return batchResultPendingConfirmation, nil
+}
+
+func (h *Handler) batchWinningChoice(req BatchPredictionsRequest, id string) (string, error) {
+	if choiceID, ok := req.WinningChoiceIDs[id]; ok {
+		return choiceID, nil
+	}
+	if req.WinningChoiceName == "" {
+		return "", repo.ErrPredictionChoiceNotFound
+	}
+	prediction, err := h.Store.GetPrediction(id)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range prediction.Choices {
+		if strings.EqualFold(c.Name, req.WinningChoiceName) {
+			return c.ID, nil
+		}
+	}
+	return "", repo.ErrPredictionChoiceNotFound
+}
+
+func (h *Handler) batchErrorMessage(err error, id string) string {
+	switch err {
+	case repo.ErrPredictionNotFound:
+		return "Prediction not found"
+	case repo.ErrPredictionNotOpen:
+		return "Prediction is not open"
+	case repo.ErrPredictionNotInClosedState:
+		return "Prediction is not closed"
+	case repo.ErrPredictionChoiceNotFound:
+		return "Invalid winning choice"
+	case repo.ErrRevealRequired:
+		return "This prediction has a commitment, decide it on its own with the salt"
+	case repo.ErrRevealMismatch:
+		return "Winning choice and salt don't match the commitment"
+	case repo.ErrPredictionAlreadyFinished:
+		return "Prediction has already been decided or voided"
+	case repo.ErrNoPendingDecision:
+		return "No decision is waiting for confirmation"
+	case repo.ErrTokensWouldBeNegative:
+		return "A winner has already spent their payout, it can't be taken back"
+	case repo.ErrPredictionIsHouseGame:
+		return "House games are decided by the server"
+	case repo.ErrDecisionAlreadyPending:
+		return "A different decision is waiting for confirmation, cancel it first"
+	case repo.ErrDecisionNeedsDifferentAdmin:
+		return "A different admin must confirm this decision"
+	}
+	h.Logger.WithError(err).WithField("prediction_id", id).Error("failed to apply batch action")
+	return "Internal Server Error"
+}
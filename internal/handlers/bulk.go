@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/repo"
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+	"gopkg.in/yaml.v3"
+)
+
+// Bulk import and export of predictions, as YAML or CSV.
+//
+// YAML is a list of entries. CSV has a header row naming the columns, with choices and tags separated by "|":
+//
+//	name,description,choices,opens_at,closes_at,tags
+//	Coin toss,,Heads|Tails,,2026-02-08T23:30:00Z,sports|kickoff
+//
+// Times are either absolute (opens_at, closes_at) or offsets like "1h30m" from the lineup's start (opens_after,
+// closes_after), which import takes from ?start= (default now). Exports use offsets, so a lineup can be run again.
+
+const (
+	bulkFormatYAML = "yaml"
+	bulkFormatCSV  = "csv"
+
+	bulkListSeparator = "|"
+)
+
+var bulkCSVColumns = []string{
+	"name", "description", "choices", "opens_at", "closes_at", "opens_after", "closes_after", "tags",
+	"odds_visible_before_bet", "sealed", "min_bet", "max_bet", "max_bankroll_percent",
+}
+
+type PredictionBulkEntry struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description" yaml:"description,omitempty"`
+	Choices     []string `json:"choices" yaml:"choices"`
+	OpensAt     string   `json:"opens_at" yaml:"opens_at,omitempty"`
+	ClosesAt    string   `json:"closes_at" yaml:"closes_at,omitempty"`
+	// OpensAfter and ClosesAfter are offsets from the lineup's start (ex: "1h30m"), used instead of OpensAt and ClosesAt
+	OpensAfter  string   `json:"opens_after" yaml:"opens_after,omitempty"`
+	ClosesAfter string   `json:"closes_after" yaml:"closes_after,omitempty"`
+	Tags        []string `json:"tags" yaml:"tags,omitempty"`
+
+	OddsVisibleBeforeBet bool  `json:"odds_visible_before_bet" yaml:"odds_visible_before_bet,omitempty"`
+	Sealed               bool  `json:"sealed" yaml:"sealed,omitempty"`
+	MinBet               int64 `json:"min_bet" yaml:"min_bet,omitempty"`
+	MaxBet               int64 `json:"max_bet" yaml:"max_bet,omitempty"`
+	MaxBankrollPercent   int64 `json:"max_bankroll_percent" yaml:"max_bankroll_percent,omitempty"`
+}
+
+// resolveTimes turns the entry's offsets into absolute times from start.
+func (e PredictionBulkEntry) resolveTimes(start time.Time) (string, string, string) {
+	opensAt, closesAt := e.OpensAt, e.ClosesAt
+	if e.OpensAfter != "" {
+		if opensAt != "" {
+			return "", "", "Use either opens_at or opens_after"
+		}
+		offset, err := time.ParseDuration(e.OpensAfter)
+		if err != nil {
+			return "", "", "Invalid opens_after"
+		}
+		opensAt = start.Add(offset).Format(time.RFC3339)
+	}
+	if e.ClosesAfter != "" {
+		if closesAt != "" {
+			return "", "", "Use either closes_at or closes_after"
+		}
+		offset, err := time.ParseDuration(e.ClosesAfter)
+		if err != nil {
+			return "", "", "Invalid closes_after"
+		}
+		closesAt = start.Add(offset).Format(time.RFC3339)
+	}
+	return opensAt, closesAt, ""
+}
+
+// bulkCSVBool and bulkCSVInt parse optional CSV cells, with "" meaning false or 0.
+func bulkCSVBool(column, value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %v %q", column, value)
+	}
+	return parsed, nil
+}
+
+func bulkCSVInt(column, value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %v %q", column, value)
+	}
+	return parsed, nil
+}
+
+type bulkRowError struct {
+	// Row is the 1-based entry number, not counting the CSV header
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// bulkFormat picks the format from ?format=, falling back to the given header (Content-Type or Accept).
+func bulkFormat(r *http.Request, header string) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return strings.ToLower(format)
+	}
+	value := strings.ToLower(r.Header.Get(header))
+	if strings.Contains(value, "csv") {
+		return bulkFormatCSV
+	}
+	if strings.Contains(value, "yaml") {
+		return bulkFormatYAML
+	}
+	return ""
+}
+
+func splitBulkList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, bulkListSeparator) {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func decodeBulkCSV(r io.Reader) ([]PredictionBulkEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		known := false
+		for _, c := range bulkCSVColumns {
+			if c == column {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+		columns[column] = i
+	}
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("missing name column")
+	}
+	if _, ok := columns["choices"]; !ok {
+		return nil, fmt.Errorf("missing choices column")
+	}
+
+	var entries []PredictionBulkEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		get := func(column string) string {
+			i, ok := columns[column]
+			if !ok {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		entry := PredictionBulkEntry{
+			Name:        get("name"),
+			Description: get("description"),
+			Choices:     splitBulkList(get("choices")),
+			OpensAt:     get("opens_at"),
+			ClosesAt:    get("closes_at"),
+			OpensAfter:  get("opens_after"),
+			ClosesAfter: get("closes_after"),
+			Tags:        splitBulkList(get("tags")),
+		}
+		row := len(entries) + 1
+		if entry.OddsVisibleBeforeBet, err = bulkCSVBool("odds_visible_before_bet", get("odds_visible_before_bet")); err != nil {
+			return nil, fmt.Errorf("row %v: %w", row, err)
+		}
+		if entry.Sealed, err = bulkCSVBool("sealed", get("sealed")); err != nil {
+			return nil, fmt.Errorf("row %v: %w", row, err)
+		}
+		if entry.MinBet, err = bulkCSVInt("min_bet", get("min_bet")); err != nil {
+			return nil, fmt.Errorf("row %v: %w", row, err)
+		}
+		if entry.MaxBet, err = bulkCSVInt("max_bet", get("max_bet")); err != nil {
+			return nil, fmt.Errorf("row %v: %w", row, err)
+		}
+		if entry.MaxBankrollPercent, err = bulkCSVInt("max_bankroll_percent", get("max_bankroll_percent")); err != nil {
+			return nil, fmt.Errorf("row %v: %w", row, err)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func encodeBulkCSV(w io.Writer, entries []PredictionBulkEntry) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(bulkCSVColumns); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err := writer.Write([]string{
+			entry.Name,
+			entry.Description,
+			strings.Join(entry.Choices, bulkListSeparator),
+			entry.OpensAt,
+			entry.ClosesAt,
+			entry.OpensAfter,
+			entry.ClosesAfter,
+			strings.Join(entry.Tags, bulkListSeparator),
+			strconv.FormatBool(entry.OddsVisibleBeforeBet),
+			strconv.FormatBool(entry.Sealed),
+			strconv.FormatInt(entry.MinBet, 10),
+			strconv.FormatInt(entry.MaxBet, 10),
+			strconv.FormatInt(entry.MaxBankrollPercent, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// predictionDraftProblem describes what's wrong with a prediction that hasn't been created yet, or returns "" if it's fine.
+// Used for imports and player proposals.
+func predictionDraftProblem(name string, choices []string, opensAtRaw, closesAtRaw string, now time.Time) string {
+	if name == "" {
+		return "Name is required"
+	}
+	if len(choices) < 2 {
+		return "At least 2 choices required"
+	}
+	seen := map[string]struct{}{}
+	for _, choice := range choices {
+		if choice == "" {
+			return "Choice names are required"
+		}
+		if _, ok := seen[choice]; ok {
+			return "Choice names must be unique"
+		}
+		if strings.Contains(choice, bulkListSeparator) {
+			return "Choice names can't contain " + bulkListSeparator
+		}
+		seen[choice] = struct{}{}
+	}
+
+	var opensAt, closesAt time.Time
+	var err error
+	if opensAtRaw != "" {
+		opensAt, err = time.Parse(time.RFC3339, opensAtRaw)
+		if err != nil {
+			return "Invalid opens_at"
+		}
+	}
+	if closesAtRaw != "" {
+		closesAt, err = time.Parse(time.RFC3339, closesAtRaw)
+		if err != nil {
+			return "Invalid closes_at"
+		}
+		if !closesAt.After(now) {
+			return "closes_at is in the past"
+		}
+		if opensAtRaw != "" && !closesAt.After(opensAt) {
+			return "closes_at must be after opens_at"
+		}
+	}
+	return ""
+}
+
+func (h *Handler) ImportPredictions(w http.ResponseWriter, r *http.Request) {
+	var (
+		entries []PredictionBulkEntry
+		err     error
+	)
+	switch bulkFormat(r, "Content-Type") {
+	case bulkFormatYAML:
+		err = yaml.NewDecoder(r.Body).Decode(&entries)
+		if err == io.EOF {
+			err = nil
+		}
+	case bulkFormatCSV:
+		entries, err = decodeBulkCSV(r.Body)
+	default:
+		h.errorResponse(w, http.StatusBadRequest, "Unknown format, send YAML or CSV")
+		return
+	}
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return
+	}
+
+	if len(entries) == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "No predictions to import")
+		return
+	}
+
+	occasionID := r.URL.Query().Get("occasion_id")
+	if occasionID != "" {
+		if _, err := h.Store.GetOccasion(occasionID); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Occasion not found")
+			return
+		}
+	}
+
+	now := time.Now()
+	start := now
+	if raw := r.URL.Query().Get("start"); raw != "" {
+		start, err = time.Parse(time.RFC3339, raw)
+		if err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid start")
+			return
+		}
+	}
+
+	rowErrors := []bulkRowError{}
+	for i := range entries {
+		entry := &entries[i]
+		opensAt, closesAt, problem := entry.resolveTimes(start)
+		if problem == "" {
+			entry.OpensAt, entry.ClosesAt, entry.OpensAfter, entry.ClosesAfter = opensAt, closesAt, "", ""
+			problem = predictionDraftProblem(entry.Name, entry.Choices, entry.OpensAt, entry.ClosesAt, now)
+		}
+		if problem == "" {
+			problem = betLimitsProblem(types.Prediction{MinBet: entry.MinBet, MaxBet: entry.MaxBet, MaxBankrollPercent: entry.MaxBankrollPercent})
+		}
+		if problem != "" {
+			rowErrors = append(rowErrors, bulkRowError{Row: i + 1, Error: problem})
+		}
+	}
+	if len(rowErrors) > 0 {
+		h.jsonResponse(w, http.StatusBadRequest, map[string]any{
+			"error":  "Nothing was imported, fix these rows and try again",
+			"errors": rowErrors,
+		})
+		return
+	}
+
+	predictions := make([]types.Prediction, 0, len(entries))
+	for _, entry := range entries {
+		predictionID, err := repo.NewID()
+		if err != nil {
+			h.Logger.WithError(err).Error("failed to generate prediction ID")
+			h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+
+		choices, err := newChoices(entry.Choices)
+		if err != nil {
+			h.Logger.WithError(err).Error("failed to generate choice ID")
+			h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+
+		status := types.PredictionStatusOpen
+		if entry.OpensAt != "" {
+			opensAt, _ := time.Parse(time.RFC3339, entry.OpensAt)
+			if now.Before(opensAt) {
+				status = types.PredictionStatusScheduled
+			}
+		}
+
+		predictions = append(predictions, types.Prediction{
+			ID:          predictionID,
+			CreatedAt:   now.Format(time.RFC3339),
+			Name:        entry.Name,
+			Description: entry.Description,
+			Status:      status,
+			OpensAt:     entry.OpensAt,
+			ClosesAt:    entry.ClosesAt,
+			Choices:     choices,
+			OccasionID:  occasionID,
+			Tags:        types.NormalizeTags(entry.Tags),
+
+			OddsVisibleBeforeBet: entry.OddsVisibleBeforeBet,
+			Sealed:               entry.Sealed,
+			MinBet:               entry.MinBet,
+			MaxBet:               entry.MaxBet,
+			MaxBankrollPercent:   entry.MaxBankrollPercent,
+		})
+	}
+
+	if err := h.Store.AddPredictions(predictions); err != nil {
+		h.Logger.WithError(err).Error("failed to import predictions")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.EventHub.EmitPredictions()
+
+	h.jsonResponse(w, http.StatusCreated, predictions)
+}
+
+// ExportPredictions writes predictions in the same format ImportPredictions reads, with times as offsets from when
+// the first of them opened (or was created). It takes the same filters as ListPredictions.
+func (h *Handler) ExportPredictions(w http.ResponseWriter, r *http.Request) {
+	format := bulkFormat(r, "Accept")
+	if format == "" {
+		format = bulkFormatYAML
+	}
+	if format != bulkFormatYAML && format != bulkFormatCSV {
+		h.errorResponse(w, http.StatusBadRequest, "Unknown format, use yaml or csv")
+		return
+	}
+
+	results, err := filterPredictions(r, h.Store.ListPredictionsWithOdds())
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid since or until")
+		return
+	}
+
+	// oldest first, so the lineup is in the order it was created
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Prediction.CreatedAt != results[j].Prediction.CreatedAt {
+			return results[i].Prediction.CreatedAt < results[j].Prediction.CreatedAt
+		}
+		return results[i].Prediction.ID < results[j].Prediction.ID
+	})
+
+	// the lineup starts when its first prediction opened
+	var start time.Time
+	for _, result := range results {
+		opened := result.Prediction.CreatedAt
+		if result.Prediction.OpensAt != "" {
+			opened = result.Prediction.OpensAt
+		}
+		if t, err := time.Parse(time.RFC3339, opened); err == nil && (start.IsZero() || t.Before(start)) {
+			start = t
+		}
+	}
+	offset := func(raw string, extended int64) string {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return ""
+		}
+		return t.Add(-time.Duration(extended) * time.Second).Sub(start).String()
+	}
+
+	entries := make([]PredictionBulkEntry, 0, len(results))
+	for _, result := range results {
+		p := result.Prediction
+		choices := make([]string, 0, len(p.Choices))
+		for _, c := range p.Choices {
+			choices = append(choices, c.Name)
+		}
+		entries = append(entries, PredictionBulkEntry{
+			Name:        p.Name,
+			Description: p.Description,
+			Choices:     choices,
+			OpensAfter:  offset(p.OpensAt, 0),
+			// without anti-sniping extensions, which belong to that night's bets
+			ClosesAfter: offset(p.ClosesAt, p.ClosesAtExtendedSeconds),
+			Tags:        p.Tags,
+
+			OddsVisibleBeforeBet: p.OddsVisibleBeforeBet,
+			Sealed:               p.Sealed,
+			MinBet:               p.MinBet,
+			MaxBet:               p.MaxBet,
+			MaxBankrollPercent:   p.MaxBankrollPercent,
+		})
+	}
+
+	if format == bulkFormatCSV {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="predictions.csv"`)
+		err = encodeBulkCSV(w, entries)
+	} else {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("Content-Disposition", `attachment; filename="predictions.yaml"`)
+		encoder := yaml.NewEncoder(w)
+		err = encoder.Encode(entries)
+		if err == nil {
+			err = encoder.Close()
+		}
+	}
+	if err != nil {
+		h.Logger.WithError(err).Warn("failed to write prediction export")
+	}
+}
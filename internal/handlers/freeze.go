@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/repo"
+)
+
+// Market freeze: a global maintenance switch for when something goes wrong mid-event.
+
+// rejectWhileFrozen wraps endpoints that move tokens or coins so they're refused while the market is frozen.
+// The store checks again as it makes the change (returning repo.ErrMarketFrozen), so a request that gets past this
+// just as the market freezes is still refused.
+func (h *Handler) rejectWhileFrozen(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.Store.IsFrozen() {
+			h.marketFrozenResponse(w)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (h *Handler) marketFrozenResponse(w http.ResponseWriter) {
+	message := "The market is frozen, try again once it's back"
+	if freeze := h.Store.GetFreeze(); freeze.Reason != "" {
+		message = "The market is frozen: " + freeze.Reason
+	}
+	h.errorCodeResponse(w, http.StatusServiceUnavailable, "market_frozen", message)
+}
+
+func (h *Handler) GetFreeze(w http.ResponseWriter, r *http.Request) {
+	h.jsonResponse(w, http.StatusOK, h.Store.GetFreeze())
+}
+
+type FreezeMarketRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *Handler) FreezeMarket(w http.ResponseWriter, r *http.Request) {
+	admin, _ := h.getAuthenticatedUser(r)
+
+	var req FreezeMarketRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	freeze, err := h.Store.Freeze(admin.ID, req.Reason)
+	if err == repo.ErrMarketFrozen {
+		h.errorResponse(w, http.StatusConflict, "The market is already frozen")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to freeze market")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.Logger.WithField("admin_id", admin.ID).WithField("reason", req.Reason).Warn("market frozen")
+	h.EventHub.EmitFrozen()
+
+	h.jsonResponse(w, http.StatusOK, freeze)
+}
+
+func (h *Handler) ThawMarket(w http.ResponseWriter, r *http.Request) {
+	admin, _ := h.getAuthenticatedUser(r)
+
+	moved, err := h.Store.Thaw()
+	if err == repo.ErrMarketNotFrozen {
+		h.errorResponse(w, http.StatusConflict, "The market is not frozen")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to thaw market")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.Logger.WithField("admin_id", admin.ID).WithField("deadlines_moved", len(moved)).Warn("market thawed")
+	h.EventHub.EmitThawed()
+	for _, id := range moved {
+		if p, err := h.Store.GetPrediction(id); err == nil {
+			h.EventHub.EmitDeadlineExtended(id, p.ClosesAt)
+		}
+	}
+	h.EventHub.EmitPredictions()
+
+	h.jsonResponse(w, http.StatusOK, h.Store.GetFreeze())
+}
@@ -5,6 +5,8 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"regexp"
 	"sort"
@@ -27,6 +29,38 @@ type Handler struct {
 	StartingTokens int64
 	StartingCoins  int64
 	EventHub       *events.Hub
+
+	// ClosingSoonOffsets are how long before ClosesAt to warn users who haven't bet yet, e.g. 5m and 1m
+	ClosingSoonOffsets []time.Duration
+	// ProposalRewardCoins are given to players whose proposed predictions get approved
+	ProposalRewardCoins int64
+	// DecisionConfirmationThreshold is the pool size above which deciding or voiding a prediction
+	// needs a second admin to confirm. 0 disables confirmations.
+	DecisionConfirmationThreshold int64
+	// DisputeWindow is how long after a decision bettors can dispute it. Achievements and coins from the decision
+	// are held until it ends. 0 disables disputes.
+	DisputeWindow time.Duration
+	// OracleClient fetches poll oracle endpoints. Defaults to a client with a 10 second timeout.
+	OracleClient *http.Client
+	// HouseGames are the server-run games Sweep keeps a round of going for. Empty disables house games.
+	HouseGames []types.HouseGameKind
+	// HouseGameInterval is how often a new round of each house game starts
+	HouseGameInterval time.Duration
+	// HouseGameBetWindow is how long each round takes bets for before it's rolled
+	HouseGameBetWindow time.Duration
+
+	closingSoonMu   sync.Mutex
+	closingSoonSent map[string]closingSoonState // prediction ID -> warnings sent
+
+	houseGamesMu sync.Mutex
+
+	// changeMu is held by audited admin changes, Sweep and oracle results, so an audit diff only shows its own change
+	changeMu sync.Mutex
+}
+
+type closingSoonState struct {
+	closesAt string
+	sent     map[time.Duration]struct{}
 }
 
 func generateSessionToken() (string, error) {
@@ -47,6 +81,11 @@ func (h *Handler) errorResponse(w http.ResponseWriter, status int, message strin
 	h.jsonResponse(w, status, map[string]string{"error": message})
 }
 
+// errorCodeResponse is errorResponse plus a stable machine-readable code, for errors clients want to tell apart.
+func (h *Handler) errorCodeResponse(w http.ResponseWriter, status int, code, message string) {
+	h.jsonResponse(w, status, map[string]string{"error": message, "code": code})
+}
+
 // Auth middleware
 
 func (h *Handler) getAuthenticatedUser(r *http.Request) (types.User, bool) {
@@ -83,19 +122,9 @@ func (h *Handler) requireAuth(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// requireAdmin guards routes only admins can use.
 func (h *Handler) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		user, ok := h.getAuthenticatedUser(r)
-		if !ok {
-			h.errorResponse(w, http.StatusUnauthorized, "Unauthorized")
-			return
-		}
-		if !user.Admin {
-			h.errorResponse(w, http.StatusForbidden, "Admin required")
-			return
-		}
-		next(w, r)
-	}
+	return h.requirePermission(types.PermissionAdmin)(next)
 }
 
 // Achievement checking helpers
@@ -259,7 +288,11 @@ func (h *Handler) checkBetAchievements(userID string, bet types.Bet) {
 		}
 
 		// Sheep / Contrarian: check if betting with or against the crowd
-		predBets := h.Store.ListBetsByPrediction(bet.PredictionID)
+		// Sealed predictions have no visible crowd to follow, so they don't count
+		predBets := []types.Bet{}
+		if !prediction.Sealed {
+			predBets = h.Store.ListBetsByPrediction(bet.PredictionID)
+		}
 		choiceTokens := map[string]int64{}
 		otherBettorCount := 0
 		for _, pb := range predBets {
@@ -552,17 +585,99 @@ func (h *Handler) checkPostDecisionAchievements(userID string) {
 
 // Public endpoints
 
+// redactHiddenOdds strips the odds from sealed predictions, and from predictions that hide them until the caller has bet.
+// Admins see everything except sealed predictions.
+func (h *Handler) redactHiddenOdds(r *http.Request, results []types.PredictionWithOdds) {
+	user, authed := h.getAuthenticatedUser(r)
+
+	betOn := map[string]struct{}{}
+	if authed {
+		for _, bet := range h.Store.ListBetsByUser(user.ID) {
+			betOn[bet.PredictionID] = struct{}{}
+		}
+	}
+
+	for i := range results {
+		if results[i].Prediction.OddsSealed() {
+			results[i].Odds = results[i].Odds.Redacted()
+			continue
+		}
+		if authed && user.Admin {
+			continue
+		}
+		if !results[i].Prediction.HidesOddsBeforeBet() {
+			continue
+		}
+		if _, ok := betOn[results[i].Prediction.ID]; ok {
+			continue
+		}
+		results[i].Odds = results[i].Odds.Redacted()
+	}
+}
+
+// filterPredictions applies the ?occasion=, ?tag=, ?status=, ?since= and ?until= query filters.
+// tag and status may be repeated: a prediction must have every tag, and any of the statuses.
+func filterPredictions(r *http.Request, results []types.PredictionWithOdds) ([]types.PredictionWithOdds, error) {
+	tr, err := parseTimeRange(r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	occasionID := query.Get("occasion")
+	tags := query["tag"]
+	statuses := query["status"]
+
+	filtered := make([]types.PredictionWithOdds, 0, len(results))
+	for _, result := range results {
+		p := result.Prediction
+		if occasionID != "" && p.OccasionID != occasionID {
+			continue
+		}
+		hasTags := true
+		for _, tag := range tags {
+			if !p.HasTag(tag) {
+				hasTags = false
+				break
+			}
+		}
+		if !hasTags {
+			continue
+		}
+		if len(statuses) > 0 {
+			hasStatus := false
+			for _, status := range statuses {
+				if p.Status == types.PredictionStatus(status) {
+					hasStatus = true
+					break
+				}
+			}
+			if !hasStatus {
+				continue
+			}
+		}
+		if !tr.contains(p.CreatedAt) {
+			continue
+		}
+		filtered = append(filtered, result)
+	}
+	return filtered, nil
+}
+
 func (h *Handler) ListPredictions(w http.ResponseWriter, r *http.Request) {
-	results := h.Store.ListPredictionsWithOdds()
+	results, err := filterPredictions(r, h.Store.ListPredictionsWithOdds())
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid since or until")
+		return
+	}
+	h.redactHiddenOdds(r, results)
 
+	// newest first (IDs are UUIDv7)
 	sort.Slice(results, func(i, j int) bool {
-		if results[i].Prediction.CreatedAt != results[j].Prediction.CreatedAt {
-			return results[i].Prediction.CreatedAt > results[j].Prediction.CreatedAt
-		}
 		return results[i].Prediction.ID > results[j].Prediction.ID
 	})
 
-	h.jsonResponse(w, http.StatusOK, results)
+	writePage(h, w, r, results, newestFirst(func(p types.PredictionWithOdds) string { return p.Prediction.ID }))
 }
 
 func (h *Handler) GetPrediction(w http.ResponseWriter, r *http.Request) {
@@ -574,10 +689,24 @@ func (h *Handler) GetPrediction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.jsonResponse(w, http.StatusOK, result)
+	results := []types.PredictionWithOdds{result}
+	h.redactHiddenOdds(r, results)
+
+	h.jsonResponse(w, http.StatusOK, results[0])
 }
 
+// leaderboards are sorted by score, so they're paged by score too
+var leaderboardPageOrder = highestScoreFirst(
+	func(u types.LeaderboardUser) int64 { return u.Score },
+	func(u types.LeaderboardUser) string { return u.ID },
+)
+
 func (h *Handler) ShowLeaderboard(w http.ResponseWriter, r *http.Request) {
+	if occasionID := r.URL.Query().Get("occasion"); occasionID != "" {
+		h.showOccasionLeaderboard(w, r, occasionID)
+		return
+	}
+
 	users := h.Store.ListUsers()
 
 	leaderboard := make([]types.LeaderboardUser, 0, len(users))
@@ -595,6 +724,12 @@ func (h *Handler) ShowLeaderboard(w http.ResponseWriter, r *http.Request) {
 				totalLostOrAtRisk += bet.Amount
 			}
 		}
+		for _, parlay := range h.Store.ListParlaysByUser(u.ID) {
+			if parlay.Status == types.ParlayStatusPlaced || parlay.Status == types.ParlayStatusLost {
+				totalLostOrAtRisk += parlay.Amount
+			}
+		}
+		totalLostOrAtRisk += h.Store.ArchivedLostTokens(u.ID)
 		forgiveness := totalLostOrAtRisk
 		if forgiveness > h.StartingTokens {
 			forgiveness = h.StartingTokens
@@ -624,7 +759,137 @@ func (h *Handler) ShowLeaderboard(w http.ResponseWriter, r *http.Request) {
 		leaderboard[i].Rank = i + 1
 	}
 
-	h.jsonResponse(w, http.StatusOK, leaderboard)
+	writePage(h, w, r, leaderboard, leaderboardPageOrder)
+}
+
+// showOccasionLeaderboard ranks players by their profit on a single occasion's decided predictions.
+// Only players who bet on the occasion are listed.
+func (h *Handler) showOccasionLeaderboard(w http.ResponseWriter, r *http.Request, occasionID string) {
+	if _, err := h.Store.GetOccasion(occasionID); err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Occasion not found")
+		return
+	}
+
+	inOccasion := map[string]struct{}{}
+	for _, p := range h.Store.ListPredictions() {
+		if p.OccasionID == occasionID {
+			inOccasion[p.ID] = struct{}{}
+		}
+	}
+	for _, p := range h.Store.ListArchivedPredictionsWithOdds() {
+		if p.Prediction.OccasionID == occasionID {
+			inOccasion[p.Prediction.ID] = struct{}{}
+		}
+	}
+
+	users := h.Store.ListUsers()
+
+	leaderboard := make([]types.LeaderboardUser, 0, len(users))
+	for _, u := range users {
+		if u.Admin {
+			continue // exclude admins from leaderboard
+		}
+
+		var score int64
+		participated := false
+		for _, bet := range append(h.Store.ListBetsByUser(u.ID), h.Store.ListArchivedBetsByUser(u.ID)...) {
+			if _, ok := inOccasion[bet.PredictionID]; !ok {
+				continue
+			}
+			participated = true
+			switch bet.Status {
+			case types.BetStatusWon:
+				score += bet.WonAmount - bet.Amount
+			case types.BetStatusLost:
+				score -= bet.Amount
+			}
+		}
+		if !participated {
+			continue
+		}
+
+		leaderboard = append(leaderboard, types.LeaderboardUser{
+			ID:           u.ID,
+			Name:         u.Name,
+			Tokens:       u.Tokens,
+			Score:        score,
+			Achievements: h.Store.GetUserAchievementIDs(u.ID),
+			Cosmetics:    h.Store.GetUserCosmetics(u.ID),
+		})
+	}
+
+	// Sort by score descending
+	sort.Slice(leaderboard, func(i, j int) bool {
+		if leaderboard[i].Score != leaderboard[j].Score {
+			return leaderboard[i].Score > leaderboard[j].Score
+		}
+		return strings.Compare(leaderboard[i].ID, leaderboard[j].ID) == -1
+	})
+
+	// Assign ranks
+	for i := range leaderboard {
+		leaderboard[i].Rank = i + 1
+	}
+
+	writePage(h, w, r, leaderboard, leaderboardPageOrder)
+}
+
+// Archive endpoints (read-only)
+
+func (h *Handler) ListArchivedPredictions(w http.ResponseWriter, r *http.Request) {
+	results, err := filterPredictions(r, h.Store.ListArchivedPredictionsWithOdds())
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid since or until")
+		return
+	}
+
+	// newest first (IDs are UUIDv7)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Prediction.ID > results[j].Prediction.ID
+	})
+
+	writePage(h, w, r, results, newestFirst(func(p types.PredictionWithOdds) string { return p.Prediction.ID }))
+}
+
+func (h *Handler) GetArchivedPrediction(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	result, err := h.Store.GetArchivedPredictionWithOdds(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, result)
+}
+
+func (h *Handler) GetMyArchivedBets(w http.ResponseWriter, r *http.Request) {
+	user, _ := h.getAuthenticatedUser(r)
+	bets, err := filterBets(r, h.Store.ListArchivedBetsByUser(user.ID))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid since or until")
+		return
+	}
+
+	// Sort by ID descending (UUIDv7, so newest first)
+	sort.Slice(bets, func(i, j int) bool {
+		return bets[i].ID > bets[j].ID
+	})
+
+	writePage(h, w, r, bets, newestFirst(func(b types.Bet) string { return b.ID }))
+}
+
+func (h *Handler) ListOccasions(w http.ResponseWriter, r *http.Request) {
+	occasions := h.Store.ListOccasions()
+
+	sort.Slice(occasions, func(i, j int) bool {
+		if occasions[i].CreatedAt != occasions[j].CreatedAt {
+			return occasions[i].CreatedAt > occasions[j].CreatedAt
+		}
+		return occasions[i].ID > occasions[j].ID
+	})
+
+	h.jsonResponse(w, http.StatusOK, occasions)
 }
 
 func (h *Handler) GetAchievements(w http.ResponseWriter, r *http.Request) {
@@ -681,12 +946,13 @@ func (h *Handler) ClaimMinigameCoins(w http.ResponseWriter, r *http.Request) {
 		coinsEarned = 5
 	}
 
-	if coinsEarned > 0 {
-		if err := h.Store.AddCoins(user.ID, coinsEarned); err != nil {
-			h.Logger.WithError(err).Error("failed to award minigame coins")
-			h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
-			return
-		}
+	if err := h.Store.ClaimMinigameCoins(user.ID, coinsEarned); err == repo.ErrMarketFrozen {
+		h.marketFrozenResponse(w)
+		return
+	} else if err != nil {
+		h.Logger.WithError(err).Error("failed to award minigame coins")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
 	}
 
 	// Track plays
@@ -782,6 +1048,9 @@ func (h *Handler) BuyShopItem(w http.ResponseWriter, r *http.Request) {
 	if err := h.Store.DeductCoins(user.ID, item.Price); err == repo.ErrInsufficientCoins {
 		h.errorResponse(w, http.StatusBadRequest, "Insufficient coins")
 		return
+	} else if err == repo.ErrMarketFrozen {
+		h.marketFrozenResponse(w)
+		return
 	} else if err != nil {
 		h.Logger.WithError(err).Error("failed to deduct coins")
 		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
@@ -1036,17 +1305,18 @@ func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) GetMyBets(w http.ResponseWriter, r *http.Request) {
 	user, _ := h.getAuthenticatedUser(r)
-	bets := h.Store.ListBetsByUser(user.ID)
+	bets, err := filterBets(r, h.Store.ListBetsByUser(user.ID))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid since or until")
+		return
+	}
 
-	// Sort by created_at descending
+	// Sort by ID descending (UUIDv7, so newest first)
 	sort.Slice(bets, func(i, j int) bool {
-		if bets[i].CreatedAt != bets[j].CreatedAt {
-			return bets[i].CreatedAt > bets[j].CreatedAt
-		}
 		return bets[i].ID > bets[j].ID
 	})
 
-	h.jsonResponse(w, http.StatusOK, bets)
+	writePage(h, w, r, bets, newestFirst(func(b types.Bet) string { return b.ID }))
 }
 
 type PlaceBetRequest struct {
@@ -1082,6 +1352,10 @@ func (h *Handler) PlaceBet(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = h.Store.CreateBet(bet)
+	if err == repo.ErrMarketFrozen {
+		h.marketFrozenResponse(w)
+		return
+	}
 	if err == repo.ErrBetAmountMustBePositive {
 		h.errorResponse(w, http.StatusBadRequest, "Amount must be positive")
 		return
@@ -1102,6 +1376,9 @@ func (h *Handler) PlaceBet(w http.ResponseWriter, r *http.Request) {
 		h.errorResponse(w, http.StatusBadRequest, "Insufficient tokens")
 		return
 	}
+	if h.betLimitErrorResponse(w, err, bet.PredictionID) {
+		return
+	}
 	if err != nil {
 		h.Logger.WithError(err).Error("failed to place bet")
 		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
@@ -1116,9 +1393,43 @@ func (h *Handler) PlaceBet(w http.ResponseWriter, r *http.Request) {
 	// Check achievements
 	h.checkBetAchievements(user.ID, bet)
 
+	// Late bets may push the deadline back. Done after achievements so Last Second Larry sees the old deadline.
+	h.applyAntiSnipe(bet.PredictionID)
+
 	h.jsonResponse(w, http.StatusCreated, bet)
 }
 
+func (h *Handler) applyAntiSnipe(predictionID string) {
+	closesAt, extended, err := h.Store.ExtendClosesAtForLateBet(predictionID, time.Now())
+	if err != nil {
+		h.Logger.WithError(err).WithField("prediction_id", predictionID).Warn("failed to apply anti-snipe rule")
+		return
+	}
+	if extended {
+		h.Logger.WithField("prediction_id", predictionID).WithField("closes_at", closesAt).Info("anti-snipe: extended prediction")
+		h.EventHub.EmitDeadlineExtended(predictionID, closesAt)
+		h.EventHub.EmitPredictions()
+	}
+}
+
+// betLimitErrorResponse writes a response if err is one of the per-prediction bet limit errors.
+func (h *Handler) betLimitErrorResponse(w http.ResponseWriter, err error, predictionID string) bool {
+	if err != repo.ErrBetBelowMinimum && err != repo.ErrBetAboveMaximum && err != repo.ErrBetAboveBankrollPercent {
+		return false
+	}
+
+	prediction, _ := h.Store.GetPrediction(predictionID)
+	switch err {
+	case repo.ErrBetBelowMinimum:
+		h.errorCodeResponse(w, http.StatusBadRequest, "bet_below_minimum", fmt.Sprintf("Minimum bet is %d tokens", prediction.MinBet))
+	case repo.ErrBetAboveMaximum:
+		h.errorCodeResponse(w, http.StatusBadRequest, "bet_above_maximum", fmt.Sprintf("Maximum bet is %d tokens", prediction.MaxBet))
+	case repo.ErrBetAboveBankrollPercent:
+		h.errorCodeResponse(w, http.StatusBadRequest, "bet_above_bankroll_percent", fmt.Sprintf("You can bet at most %d%% of your tokens", prediction.MaxBankrollPercent))
+	}
+	return true
+}
+
 type IncreaseBetRequest struct {
 	Amount int64 `json:"amount"`
 }
@@ -1145,6 +1456,10 @@ func (h *Handler) IncreaseBetAmount(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = h.Store.IncreaseBet(bet.ID, req.Amount)
+	if err == repo.ErrMarketFrozen {
+		h.marketFrozenResponse(w)
+		return
+	}
 	if err == repo.ErrBetNotActive {
 		h.errorResponse(w, http.StatusBadRequest, "Bet is not active")
 		return
@@ -1165,6 +1480,9 @@ func (h *Handler) IncreaseBetAmount(w http.ResponseWriter, r *http.Request) {
 		h.errorResponse(w, http.StatusBadRequest, "Insufficient tokens")
 		return
 	}
+	if h.betLimitErrorResponse(w, err, bet.PredictionID) {
+		return
+	}
 	if err != nil {
 		h.Logger.WithError(err).Error("failed to updateplace bet")
 		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
@@ -1185,298 +1503,2274 @@ func (h *Handler) IncreaseBetAmount(w http.ResponseWriter, r *http.Request) {
 	h.grantAchievement(user.ID, types.AchievementIncreasedBet)
 	h.checkBetAmountAchievements(user.ID, req.Amount)
 
+	h.applyAntiSnipe(bet.PredictionID)
+
 	h.jsonResponse(w, http.StatusOK, bet)
 }
 
-// Admin endpoints
-
-type CreatePredictionRequest struct {
-	Name                 string                   `json:"name"`
-	Description          string                   `json:"description"`
-	ClosesAt             string                   `json:"closes_at"`
-	Choices              []types.PredictionChoice `json:"choices"`
-	OddsVisibleBeforeBet bool                     `json:"odds_visible_before_bet"`
+type PlaceParlayRequest struct {
+	Legs []struct {
+		PredictionID       string `json:"prediction_id"`
+		PredictionChoiceID string `json:"prediction_choice_id"`
+	} `json:"legs"`
+	Amount int64 `json:"amount"`
 }
 
-func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
-	users := h.Store.ListUsers()
-
-	h.jsonResponse(w, http.StatusOK, users)
-}
+func (h *Handler) PlaceParlay(w http.ResponseWriter, r *http.Request) {
+	user, _ := h.getAuthenticatedUser(r)
 
-func (h *Handler) CreatePrediction(w http.ResponseWriter, r *http.Request) {
-	var req CreatePredictionRequest
+	var req PlaceParlayRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	if req.Name == "" {
-		h.errorResponse(w, http.StatusBadRequest, "Name is required")
-		return
-	}
-
-	if len(req.Choices) < 2 {
-		h.errorResponse(w, http.StatusBadRequest, "At least 2 choices required")
-		return
-	}
-
-	predictionID, err := repo.NewID()
+	parlayID, err := repo.NewID()
 	if err != nil {
-		h.Logger.WithError(err).Error("failed to generate prediction ID")
+		h.Logger.WithError(err).Error("failed to generate parlay ID")
 		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
 	}
 
-	// Generate IDs for choices if not provided
-	for i := range req.Choices {
-		if req.Choices[i].ID == "" {
-			choiceID, err := repo.NewID()
-			if err != nil {
-				h.Logger.WithError(err).Error("failed to generate choice ID")
-				h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
-				return
-			}
-			req.Choices[i].ID = choiceID
+	legs := make([]types.ParlayLeg, len(req.Legs))
+	for i := range req.Legs {
+		legs[i] = types.ParlayLeg{
+			PredictionID:       req.Legs[i].PredictionID,
+			PredictionChoiceID: req.Legs[i].PredictionChoiceID,
+			Status:             types.ParlayLegStatusPending,
 		}
 	}
 
-	prediction := types.Prediction{
-		ID:                   predictionID,
-		CreatedAt:            time.Now().Format(time.RFC3339),
-		Name:                 req.Name,
-		Description:          req.Description,
-		Status:               types.PredictionStatusOpen,
-		ClosesAt:             req.ClosesAt,
-		Choices:              req.Choices,
-		OddsVisibleBeforeBet: req.OddsVisibleBeforeBet,
+	parlay := types.Parlay{
+		ID:        parlayID,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		UserID:    user.ID,
+		Amount:    req.Amount,
+		Legs:      legs,
+		Status:    types.ParlayStatusPlaced,
 	}
 
-	if err := h.Store.PutPrediction(prediction); err != nil {
-		h.Logger.WithError(err).Error("failed to create prediction")
-		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+	legPredictionID, err := h.Store.CreateParlay(parlay)
+	if err == repo.ErrMarketFrozen {
+		h.marketFrozenResponse(w)
 		return
 	}
-
-	h.EventHub.EmitPredictions()
-
-	h.jsonResponse(w, http.StatusCreated, prediction)
-}
-
-type UpdatePredictionRequest struct {
-	Name        *string `json:"name,omitempty"`
-	Description *string `json:"description,omitempty"`
-	ClosesAt    *string `json:"closes_at,omitempty"`
-	// Choices              []types.PredictionChoice `json:"choices,omitempty"`
-	OddsVisibleBeforeBet *bool `json:"odds_visible_before_bet,omitempty"`
-}
-
-func (h *Handler) UpdatePrediction(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-
-	prediction, err := h.Store.GetPrediction(id)
-	if err != nil {
-		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+	if h.betLimitErrorResponse(w, err, legPredictionID) {
 		return
 	}
-
-	var req UpdatePredictionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+	if err == repo.ErrBetAmountMustBePositive {
+		h.errorResponse(w, http.StatusBadRequest, "Amount must be positive")
 		return
 	}
-
-	if req.Name != nil {
+	if err == repo.ErrParlayNeedsMultipleLegs {
+		h.errorResponse(w, http.StatusBadRequest, "A parlay needs at least 2 legs")
+		return
+	}
+	if err == repo.ErrParlayDuplicatePrediction {
+		h.errorResponse(w, http.StatusBadRequest, "Each leg must be on a different prediction")
+		return
+	}
+	if err == repo.ErrPredictionNotFound {
+		h.errorResponse(w, http.StatusBadRequest, "Prediction not found")
+		return
+	}
+	if err == repo.ErrPredictionNotOpen {
+		h.errorResponse(w, http.StatusBadRequest, "Prediction is not open for betting")
+		return
+	}
+	if err == repo.ErrPredictionChoiceNotFound {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid choice")
+		return
+	}
+	if err == repo.ErrTokensWouldBeNegative {
+		h.errorResponse(w, http.StatusBadRequest, "Insufficient tokens")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to place parlay")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.EventHub.EmitLeaderboard()
+	h.EventHub.EmitParlays(user.ID)
+
+	h.jsonResponse(w, http.StatusCreated, parlay)
+}
+
+func (h *Handler) GetMyParlays(w http.ResponseWriter, r *http.Request) {
+	user, _ := h.getAuthenticatedUser(r)
+	parlays := h.Store.ListParlaysByUser(user.ID)
+
+	// Sort by created_at descending
+	sort.Slice(parlays, func(i, j int) bool {
+		if parlays[i].CreatedAt != parlays[j].CreatedAt {
+			return parlays[i].CreatedAt > parlays[j].CreatedAt
+		}
+		return parlays[i].ID > parlays[j].ID
+	})
+
+	h.jsonResponse(w, http.StatusOK, parlays)
+}
+
+// Admin endpoints
+
+type CreatePredictionRequest struct {
+	Name                 string                    `json:"name"`
+	Description          string                    `json:"description"`
+	OpensAt              string                    `json:"opens_at"`
+	ClosesAt             string                    `json:"closes_at"`
+	Choices              []types.PredictionChoice  `json:"choices"`
+	OddsVisibleBeforeBet bool                      `json:"odds_visible_before_bet"`
+	ParentPredictionID   string                    `json:"parent_prediction_id"`
+	ParentChoiceID       string                    `json:"parent_choice_id"`
+	AntiSnipe            *types.AntiSnipeRule      `json:"anti_snipe"`
+	VoteResolution       *types.VoteResolutionRule `json:"vote_resolution"`
+	Sealed               bool                      `json:"sealed"`
+	MinBet               int64                     `json:"min_bet"`
+	MaxBet               int64                     `json:"max_bet"`
+	MaxBankrollPercent   int64                     `json:"max_bankroll_percent"`
+	OccasionID           string                    `json:"occasion_id"`
+	Tags                 []string                  `json:"tags"`
+	// Commitment is types.CommitmentHash of the answer, for trivia where the host already knows it
+	Commitment string `json:"commitment"`
+}
+
+const voteResolutionProblem = "Vote resolution needs voters of non_bettors or all, a positive quorum, a supermajority above 50 and at most 100, and a non-negative voting window"
+
+// betLimitsProblem describes what's wrong with a prediction's bet limits, or returns "" if they're fine.
+func betLimitsProblem(p types.Prediction) string {
+	if p.MinBet < 0 || p.MaxBet < 0 {
+		return "Bet limits can't be negative"
+	}
+	if p.MinBet > 0 && p.MaxBet > 0 && p.MinBet > p.MaxBet {
+		return "Minimum bet can't be above maximum bet"
+	}
+	if p.MaxBankrollPercent < 0 || p.MaxBankrollPercent > 100 {
+		return "Max percent of bankroll must be between 0 and 100"
+	}
+	return ""
+}
+
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	users := h.Store.ListUsers()
+
+	// Sort by ID (UUIDv7, so oldest first)
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	writePage(h, w, r, users, oldestFirst(func(u types.User) string { return u.ID }))
+}
+
+func (h *Handler) ListBets(w http.ResponseWriter, r *http.Request) {
+	bets, err := filterBets(r, h.Store.ListBets())
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid since or until")
+		return
+	}
+
+	// Sort by ID descending (UUIDv7, so newest first)
+	sort.Slice(bets, func(i, j int) bool {
+		return bets[i].ID > bets[j].ID
+	})
+
+	// sealed pools stay hidden from admins too, until they close
+	sealed := map[string]bool{}
+	for i := range bets {
+		isSealed, ok := sealed[bets[i].PredictionID]
+		if !ok {
+			prediction, err := h.Store.GetPrediction(bets[i].PredictionID)
+			isSealed = err == nil && prediction.OddsSealed()
+			sealed[bets[i].PredictionID] = isSealed
+		}
+		if isSealed {
+			bets[i] = bets[i].Redacted()
+		}
+	}
+
+	writePage(h, w, r, bets, newestFirst(func(b types.Bet) string { return b.ID }))
+}
+
+func (h *Handler) CreatePrediction(w http.ResponseWriter, r *http.Request) {
+	var req CreatePredictionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	if len(req.Choices) < 2 {
+		h.errorResponse(w, http.StatusBadRequest, "At least 2 choices required")
+		return
+	}
+
+	if req.AntiSnipe != nil && !req.AntiSnipe.Valid() {
+		h.errorResponse(w, http.StatusBadRequest, "Anti-snipe window, extension and cap must all be positive")
+		return
+	}
+
+	if req.VoteResolution != nil && !req.VoteResolution.Valid() {
+		h.errorResponse(w, http.StatusBadRequest, voteResolutionProblem)
+		return
+	}
+
+	if req.OccasionID != "" {
+		if _, err := h.Store.GetOccasion(req.OccasionID); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Occasion not found")
+			return
+		}
+	}
+
+	if req.Commitment != "" {
+		if problem := commitmentProblem(req.Commitment, req.Choices, req.VoteResolution); problem != "" {
+			h.errorResponse(w, http.StatusBadRequest, problem)
+			return
+		}
+	}
+
+	status := types.PredictionStatusOpen
+	if req.ParentPredictionID != "" {
+		parent, err := h.Store.GetPrediction(req.ParentPredictionID)
+		if err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Parent prediction not found")
+			return
+		}
+		validChoice := false
+		for _, c := range parent.Choices {
+			if c.ID == req.ParentChoiceID {
+				validChoice = true
+				break
+			}
+		}
+		if !validChoice {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid parent choice")
+			return
+		}
+		// stays pending until Sweep sees the parent decided
+		status = types.PredictionStatusPending
+	}
+
+	if req.OpensAt != "" {
+		opensAt, err := time.Parse(time.RFC3339, req.OpensAt)
+		if err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid opens_at")
+			return
+		}
+		if status == types.PredictionStatusOpen && time.Now().Before(opensAt) {
+			// stays scheduled until Sweep sees OpensAt pass
+			status = types.PredictionStatusScheduled
+		}
+	}
+
+	predictionID, err := repo.NewID()
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to generate prediction ID")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	// Generate IDs for choices if not provided
+	for i := range req.Choices {
+		if req.Choices[i].ID == "" {
+			choiceID, err := repo.NewID()
+			if err != nil {
+				h.Logger.WithError(err).Error("failed to generate choice ID")
+				h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+				return
+			}
+			req.Choices[i].ID = choiceID
+		}
+	}
+
+	prediction := types.Prediction{
+		ID:                   predictionID,
+		CreatedAt:            time.Now().Format(time.RFC3339),
+		Name:                 req.Name,
+		Description:          req.Description,
+		Status:               status,
+		OpensAt:              req.OpensAt,
+		ClosesAt:             req.ClosesAt,
+		Choices:              req.Choices,
+		OddsVisibleBeforeBet: req.OddsVisibleBeforeBet,
+		ParentPredictionID:   req.ParentPredictionID,
+		ParentChoiceID:       req.ParentChoiceID,
+		AntiSnipe:            req.AntiSnipe,
+		VoteResolution:       req.VoteResolution,
+		Sealed:               req.Sealed,
+		MinBet:               req.MinBet,
+		MaxBet:               req.MaxBet,
+		MaxBankrollPercent:   req.MaxBankrollPercent,
+		OccasionID:           req.OccasionID,
+		Tags:                 types.NormalizeTags(req.Tags),
+		Commitment:           strings.ToLower(req.Commitment),
+	}
+
+	h.storeNewPrediction(w, prediction)
+}
+
+// commitmentProblem describes what's wrong with committing to an answer for these choices, or returns "" if it's fine.
+func commitmentProblem(commitment string, choices []types.PredictionChoice, voteResolution *types.VoteResolutionRule) string {
+	if !types.ValidCommitment(commitment) {
+		return "Commitment must be a hex-encoded SHA-256 hash"
+	}
+	if voteResolution != nil {
+		return "Committed predictions are decided by the host, not by vote"
+	}
+	names := map[string]struct{}{}
+	for _, c := range choices {
+		if _, ok := names[c.Name]; ok {
+			return "Committed predictions need unique choice names"
+		}
+		names[c.Name] = struct{}{}
+	}
+	return ""
+}
+
+// storeNewPrediction validates the bet limits of a freshly built prediction, saves it and responds with it.
+func (h *Handler) storeNewPrediction(w http.ResponseWriter, prediction types.Prediction) {
+	if problem := betLimitsProblem(prediction); problem != "" {
+		h.errorResponse(w, http.StatusBadRequest, problem)
+		return
+	}
+
+	if err := h.Store.PutPrediction(prediction); err != nil {
+		h.Logger.WithError(err).Error("failed to create prediction")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.EventHub.EmitPredictions()
+
+	if prediction.Status == types.PredictionStatusPending {
+		h.sweep() // parent may already be decided
+	}
+
+	h.jsonResponse(w, http.StatusCreated, prediction)
+}
+
+// newChoices builds choices with freshly generated IDs.
+func newChoices(names []string) ([]types.PredictionChoice, error) {
+	choices := make([]types.PredictionChoice, 0, len(names))
+	for _, name := range names {
+		choiceID, err := repo.NewID()
+		if err != nil {
+			return nil, err
+		}
+		choices = append(choices, types.PredictionChoice{ID: choiceID, Name: name})
+	}
+	return choices, nil
+}
+
+// scheduleNewPrediction works out the status and ClosesAt of a prediction created from a template or clone.
+// closesIn is counted from opensAt if it's set, otherwise from now. A closesIn of 0 means the prediction doesn't close automatically.
+func scheduleNewPrediction(opensAt string, closesIn time.Duration) (types.PredictionStatus, string, error) {
+	status := types.PredictionStatusOpen
+	start := time.Now()
+	if opensAt != "" {
+		parsed, err := time.Parse(time.RFC3339, opensAt)
+		if err != nil {
+			return "", "", err
+		}
+		if start.Before(parsed) {
+			status = types.PredictionStatusScheduled
+			start = parsed
+		}
+	}
+
+	closesAt := ""
+	if closesIn > 0 {
+		closesAt = start.Add(closesIn).Format(time.RFC3339)
+	}
+	return status, closesAt, nil
+}
+
+type UpdatePredictionRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	OpensAt     *string `json:"opens_at,omitempty"`
+	ClosesAt    *string `json:"closes_at,omitempty"`
+	// Choices replaces the full list of choices: choices without an ID are added, and existing choices left out are removed
+	// (refunding any bets on them).
+	Choices              []types.PredictionChoice `json:"choices,omitempty"`
+	OddsVisibleBeforeBet *bool                    `json:"odds_visible_before_bet,omitempty"`
+	// AntiSnipe replaces the anti-snipe rule. Send all zeroes to remove it.
+	AntiSnipe *types.AntiSnipeRule `json:"anti_snipe,omitempty"`
+	// VoteResolution replaces the vote resolution rule. Send all zeroes to remove it.
+	VoteResolution *types.VoteResolutionRule `json:"vote_resolution,omitempty"`
+	Sealed         *bool                     `json:"sealed,omitempty"`
+
+	MinBet             *int64 `json:"min_bet,omitempty"`
+	MaxBet             *int64 `json:"max_bet,omitempty"`
+	MaxBankrollPercent *int64 `json:"max_bankroll_percent,omitempty"`
+
+	// OccasionID moves the prediction to another occasion. Send "" to remove it from its occasion.
+	OccasionID *string `json:"occasion_id,omitempty"`
+	// Tags replaces the prediction's tags.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// maxUpdateAttempts is how many times UpdatePrediction reapplies an edit that raced with another change.
+const maxUpdateAttempts = 3
+
+func (h *Handler) UpdatePrediction(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req UpdatePredictionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Generate IDs for new choices
+	for i := range req.Choices {
+		if req.Choices[i].ID == "" {
+			choiceID, err := repo.NewID()
+			if err != nil {
+				h.Logger.WithError(err).Error("failed to generate choice ID")
+				h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+				return
+			}
+			req.Choices[i].ID = choiceID
+		}
+	}
+
+	// the edit is applied to what was read, and only saved if nothing else (a sweep, an anti-snipe extension)
+	// changed the prediction in between, otherwise it's applied again to the new version
+	var prediction types.Prediction
+	var refunded []string
+	for attempt := 1; ; attempt++ {
+		read, err := h.Store.GetPrediction(id)
+		if err != nil {
+			h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+			return
+		}
+
+		prediction = read
+		if problem := h.applyPredictionUpdate(&prediction, req); problem != "" {
+			h.errorResponse(w, http.StatusBadRequest, problem)
+			return
+		}
+
+		refunded, err = h.Store.UpdatePrediction(read, prediction)
+		if err == repo.ErrPredictionChanged && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err == repo.ErrPredictionChanged {
+			h.errorResponse(w, http.StatusConflict, "Prediction is changing too quickly, try again")
+			return
+		}
+		if err == repo.ErrPredictionNotFound {
+			h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+			return
+		}
+		if err == repo.ErrPredictionNotOpen {
+			h.errorResponse(w, http.StatusBadRequest, "Can only update open predictions")
+			return
+		}
+		if err == repo.ErrPredictionNeedsChoices {
+			h.errorResponse(w, http.StatusBadRequest, "At least 2 choices required")
+			return
+		}
+		if err == repo.ErrPredictionDuplicateChoice {
+			h.errorResponse(w, http.StatusBadRequest, "Choices must be unique")
+			return
+		}
+		if err != nil {
+			h.Logger.WithError(err).Error("failed to update prediction")
+			h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+			return
+		}
+		break
+	}
+
+	h.EventHub.EmitPredictions()
+	if len(req.Choices) > 0 {
+		// removed choices may have voided parlay legs
+		h.EventHub.EmitParlaysAll()
+	}
+	if len(refunded) > 0 {
+		h.EventHub.EmitLeaderboard()
+		for _, userID := range refunded {
+			h.EventHub.EmitBets(userID)
+		}
+	}
+
+	if prediction.Status == types.PredictionStatusScheduled {
+		h.sweep() // OpensAt may have been moved into the past
+	}
+
+	h.jsonResponse(w, http.StatusOK, prediction)
+}
+
+// applyPredictionUpdate applies an edit to a prediction, returning what's wrong with the edit, if anything.
+func (h *Handler) applyPredictionUpdate(prediction *types.Prediction, req UpdatePredictionRequest) string {
+	if prediction.HouseGame != nil {
+		return "House games can't be edited"
+	}
+
+	if req.Name != nil {
 		prediction.Name = *req.Name
 	}
 	if req.Description != nil {
 		prediction.Description = *req.Description
 	}
+	if req.OpensAt != nil {
+		if prediction.Status != types.PredictionStatusScheduled {
+			return "Can only change opens_at on scheduled predictions"
+		}
+		if *req.OpensAt != "" {
+			if _, err := time.Parse(time.RFC3339, *req.OpensAt); err != nil {
+				return "Invalid opens_at"
+			}
+		}
+		prediction.OpensAt = *req.OpensAt
+	}
 	if req.ClosesAt != nil {
+		if *req.ClosesAt != "" {
+			if _, err := time.Parse(time.RFC3339, *req.ClosesAt); err != nil {
+				return "Invalid closes_at"
+			}
+		}
+		if *req.ClosesAt != prediction.ClosesAt {
+			// a new deadline gets the full anti-snipe cap again
+			prediction.ClosesAtExtendedSeconds = 0
+		}
 		prediction.ClosesAt = *req.ClosesAt
 	}
-	if req.OddsVisibleBeforeBet != nil {
-		prediction.OddsVisibleBeforeBet = *req.OddsVisibleBeforeBet
+	if req.OddsVisibleBeforeBet != nil {
+		prediction.OddsVisibleBeforeBet = *req.OddsVisibleBeforeBet
+	}
+	if req.OccasionID != nil {
+		if *req.OccasionID != "" {
+			if _, err := h.Store.GetOccasion(*req.OccasionID); err != nil {
+				return "Occasion not found"
+			}
+		}
+		prediction.OccasionID = *req.OccasionID
+	}
+	if req.Tags != nil {
+		prediction.Tags = types.NormalizeTags(req.Tags)
+	}
+	if req.MinBet != nil {
+		prediction.MinBet = *req.MinBet
+	}
+	if req.MaxBet != nil {
+		prediction.MaxBet = *req.MaxBet
+	}
+	if req.MaxBankrollPercent != nil {
+		prediction.MaxBankrollPercent = *req.MaxBankrollPercent
+	}
+	if problem := betLimitsProblem(*prediction); problem != "" {
+		return problem
+	}
+	if req.Sealed != nil {
+		if prediction.Sealed && !*req.Sealed {
+			return "Sealed predictions stay sealed until they close"
+		}
+		prediction.Sealed = *req.Sealed
+	}
+	if req.AntiSnipe != nil {
+		if *req.AntiSnipe == (types.AntiSnipeRule{}) {
+			prediction.AntiSnipe = nil
+		} else if !req.AntiSnipe.Valid() {
+			return "Anti-snipe window, extension and cap must all be positive"
+		} else {
+			prediction.AntiSnipe = req.AntiSnipe
+		}
+	}
+	if req.VoteResolution != nil {
+		if *req.VoteResolution == (types.VoteResolutionRule{}) {
+			prediction.VoteResolution = nil
+		} else if !req.VoteResolution.Valid() {
+			return voteResolutionProblem
+		} else if prediction.Commitment != "" {
+			return "Committed predictions are decided by the host, not by vote"
+		} else {
+			prediction.VoteResolution = req.VoteResolution
+		}
+	}
+	if len(req.Choices) > 0 && prediction.Commitment != "" {
+		// renaming choices would let the host switch answers
+		return "Choices of a committed prediction can't be changed"
+	}
+	if len(req.Choices) > 0 {
+		prediction.Choices = req.Choices
+	}
+
+	return ""
+}
+
+type OccasionRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (h *Handler) CreateOccasion(w http.ResponseWriter, r *http.Request) {
+	var req OccasionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	occasionID, err := repo.NewID()
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to generate occasion ID")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	occasion := types.Occasion{
+		ID:          occasionID,
+		CreatedAt:   time.Now().Format(time.RFC3339),
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	if err := h.Store.PutOccasion(occasion); err != nil {
+		h.Logger.WithError(err).Error("failed to create occasion")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.EventHub.EmitPredictions()
+
+	h.jsonResponse(w, http.StatusCreated, occasion)
+}
+
+func (h *Handler) UpdateOccasion(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	occasion, err := h.Store.GetOccasion(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Occasion not found")
+		return
+	}
+
+	var req OccasionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Name == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	occasion.Name = req.Name
+	occasion.Description = req.Description
+
+	if err := h.Store.PutOccasion(occasion); err != nil {
+		h.Logger.WithError(err).Error("failed to update occasion")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.EventHub.EmitPredictions()
+
+	h.jsonResponse(w, http.StatusOK, occasion)
+}
+
+// Proposal endpoints
+
+// maxPendingProposals is how many proposals a player can have waiting for an admin at once
+const maxPendingProposals = 5
+
+type ProposePredictionRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Choices     []string `json:"choices"`
+	ClosesAt    string   `json:"closes_at"`
+	Tags        []string `json:"tags"`
+}
+
+func (h *Handler) ProposePrediction(w http.ResponseWriter, r *http.Request) {
+	user, _ := h.getAuthenticatedUser(r)
+
+	var req ProposePredictionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if problem := predictionDraftProblem(req.Name, req.Choices, "", req.ClosesAt, time.Now()); problem != "" {
+		h.errorResponse(w, http.StatusBadRequest, problem)
+		return
+	}
+
+	proposalID, err := repo.NewID()
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to generate proposal ID")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	proposal := types.Proposal{
+		ID:          proposalID,
+		CreatedAt:   time.Now().Format(time.RFC3339),
+		UserID:      user.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		Choices:     req.Choices,
+		ClosesAt:    req.ClosesAt,
+		Tags:        types.NormalizeTags(req.Tags),
+		Status:      types.ProposalStatusPending,
+	}
+
+	err = h.Store.AddProposal(proposal, maxPendingProposals)
+	if err == repo.ErrTooManyPendingProposals {
+		h.errorResponse(w, http.StatusBadRequest, fmt.Sprintf("You already have %d proposals waiting for approval", maxPendingProposals))
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to add proposal")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.EventHub.EmitProposals()
+
+	h.jsonResponse(w, http.StatusCreated, proposal)
+}
+
+func sortProposals(proposals []types.Proposal) {
+	// newest first (IDs are UUIDv7)
+	sort.Slice(proposals, func(i, j int) bool {
+		return proposals[i].ID > proposals[j].ID
+	})
+}
+
+func (h *Handler) GetMyProposals(w http.ResponseWriter, r *http.Request) {
+	user, _ := h.getAuthenticatedUser(r)
+	proposals := h.Store.ListProposalsByUser(user.ID)
+
+	sortProposals(proposals)
+
+	writePage(h, w, r, proposals, newestFirst(func(p types.Proposal) string { return p.ID }))
+}
+
+func (h *Handler) ListProposals(w http.ResponseWriter, r *http.Request) {
+	statuses := r.URL.Query()["status"]
+
+	proposals := []types.Proposal{}
+	for _, p := range h.Store.ListProposals() {
+		if len(statuses) > 0 {
+			hasStatus := false
+			for _, status := range statuses {
+				if p.Status == types.ProposalStatus(status) {
+					hasStatus = true
+					break
+				}
+			}
+			if !hasStatus {
+				continue
+			}
+		}
+		proposals = append(proposals, p)
+	}
+
+	sortProposals(proposals)
+
+	writePage(h, w, r, proposals, newestFirst(func(p types.Proposal) string { return p.ID }))
+}
+
+// ApproveProposalRequest optionally edits the proposal before it becomes a prediction
+type ApproveProposalRequest struct {
+	Name                 *string  `json:"name,omitempty"`
+	Description          *string  `json:"description,omitempty"`
+	Choices              []string `json:"choices,omitempty"`
+	OpensAt              string   `json:"opens_at"`
+	ClosesAt             *string  `json:"closes_at,omitempty"`
+	Tags                 []string `json:"tags,omitempty"`
+	OccasionID           string   `json:"occasion_id"`
+	OddsVisibleBeforeBet bool     `json:"odds_visible_before_bet"`
+}
+
+func (h *Handler) proposalErrorResponse(w http.ResponseWriter, err error, action string) {
+	switch err {
+	case repo.ErrProposalNotFound:
+		h.errorResponse(w, http.StatusNotFound, "Proposal not found")
+	case repo.ErrProposalNotPending:
+		h.errorResponse(w, http.StatusBadRequest, "Proposal has already been resolved")
+	case repo.ErrPredictionNotFound:
+		h.errorResponse(w, http.StatusBadRequest, "Prediction not found")
+	default:
+		h.Logger.WithError(err).Error("failed to " + action + " proposal")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+	}
+}
+
+func (h *Handler) ApproveProposal(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	proposal, err := h.Store.GetProposal(id)
+	if err != nil {
+		h.proposalErrorResponse(w, err, "approve")
+		return
+	}
+
+	// approving pays the proposer, so moderators can't approve their own
+	if caller, _ := h.getAuthenticatedUser(r); proposal.UserID == caller.ID && !caller.Admin {
+		h.errorResponse(w, http.StatusForbidden, "You can't approve your own proposal")
+		return
+	}
+
+	var req ApproveProposalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Name != nil {
+		proposal.Name = *req.Name
+	}
+	if req.Description != nil {
+		proposal.Description = *req.Description
+	}
+	if req.Choices != nil {
+		proposal.Choices = req.Choices
+	}
+	if req.ClosesAt != nil {
+		proposal.ClosesAt = *req.ClosesAt
+	}
+	if req.Tags != nil {
+		proposal.Tags = types.NormalizeTags(req.Tags)
+	}
+
+	now := time.Now()
+	if problem := predictionDraftProblem(proposal.Name, proposal.Choices, req.OpensAt, proposal.ClosesAt, now); problem != "" {
+		h.errorResponse(w, http.StatusBadRequest, problem)
+		return
+	}
+
+	if req.OccasionID != "" {
+		if _, err := h.Store.GetOccasion(req.OccasionID); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Occasion not found")
+			return
+		}
+	}
+
+	status := types.PredictionStatusOpen
+	if req.OpensAt != "" {
+		opensAt, _ := time.Parse(time.RFC3339, req.OpensAt)
+		if now.Before(opensAt) {
+			status = types.PredictionStatusScheduled
+		}
+	}
+
+	predictionID, err := repo.NewID()
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to generate prediction ID")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	choices, err := newChoices(proposal.Choices)
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to generate choice ID")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	prediction := types.Prediction{
+		ID:                   predictionID,
+		CreatedAt:            now.Format(time.RFC3339),
+		Name:                 proposal.Name,
+		Description:          proposal.Description,
+		Status:               status,
+		OpensAt:              req.OpensAt,
+		ClosesAt:             proposal.ClosesAt,
+		Choices:              choices,
+		OddsVisibleBeforeBet: req.OddsVisibleBeforeBet,
+		OccasionID:           req.OccasionID,
+		Tags:                 proposal.Tags,
+	}
+
+	proposal, err = h.Store.ApproveProposal(id, prediction, h.ProposalRewardCoins)
+	if err != nil {
+		h.proposalErrorResponse(w, err, "approve")
+		return
+	}
+
+	h.EventHub.EmitPredictions()
+	h.EventHub.EmitProposals()
+
+	h.jsonResponse(w, http.StatusOK, proposal)
+}
+
+type RejectProposalRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (h *Handler) RejectProposal(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req RejectProposalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Reason == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Reason is required")
+		return
+	}
+
+	proposal, err := h.Store.RejectProposal(id, req.Reason)
+	if err != nil {
+		h.proposalErrorResponse(w, err, "reject")
+		return
+	}
+
+	h.EventHub.EmitProposals()
+
+	h.jsonResponse(w, http.StatusOK, proposal)
+}
+
+type MergeProposalRequest struct {
+	// PredictionID is the existing prediction the proposal duplicates
+	PredictionID string `json:"prediction_id"`
+}
+
+func (h *Handler) MergeProposal(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req MergeProposalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	proposal, err := h.Store.MergeProposal(id, req.PredictionID)
+	if err != nil {
+		h.proposalErrorResponse(w, err, "merge")
+		return
+	}
+
+	h.EventHub.EmitProposals()
+
+	h.jsonResponse(w, http.StatusOK, proposal)
+}
+
+// Template endpoints
+
+type TemplateRequest struct {
+	Name                 string                    `json:"name"`
+	Description          string                    `json:"description"`
+	Choices              []string                  `json:"choices"`
+	DurationSeconds      int64                     `json:"duration_seconds"`
+	OddsVisibleBeforeBet bool                      `json:"odds_visible_before_bet"`
+	AntiSnipe            *types.AntiSnipeRule      `json:"anti_snipe"`
+	VoteResolution       *types.VoteResolutionRule `json:"vote_resolution"`
+	Sealed               bool                      `json:"sealed"`
+	MinBet               int64                     `json:"min_bet"`
+	MaxBet               int64                     `json:"max_bet"`
+	MaxBankrollPercent   int64                     `json:"max_bankroll_percent"`
+	Tags                 []string                  `json:"tags"`
+}
+
+// problem describes what's wrong with the template request, or returns "" if it's fine.
+func (req TemplateRequest) problem() string {
+	if req.Name == "" {
+		return "Name is required"
+	}
+	if len(req.Choices) < 2 {
+		return "At least 2 choices required"
+	}
+	seen := map[string]struct{}{}
+	for _, choice := range req.Choices {
+		if choice == "" {
+			return "Choice names are required"
+		}
+		if _, ok := seen[choice]; ok {
+			return "Choice names must be unique"
+		}
+		seen[choice] = struct{}{}
+	}
+	if req.DurationSeconds < 0 {
+		return "Duration can't be negative"
+	}
+	if req.AntiSnipe != nil && !req.AntiSnipe.Valid() {
+		return "Anti-snipe window, extension and cap must all be positive"
+	}
+	if req.VoteResolution != nil && !req.VoteResolution.Valid() {
+		return voteResolutionProblem
+	}
+	return betLimitsProblem(types.Prediction{
+		MinBet:             req.MinBet,
+		MaxBet:             req.MaxBet,
+		MaxBankrollPercent: req.MaxBankrollPercent,
+	})
+}
+
+func (req TemplateRequest) apply(t *types.PredictionTemplate) {
+	t.Name = req.Name
+	t.Description = req.Description
+	t.Choices = req.Choices
+	t.DurationSeconds = req.DurationSeconds
+	t.OddsVisibleBeforeBet = req.OddsVisibleBeforeBet
+	t.AntiSnipe = req.AntiSnipe
+	t.VoteResolution = req.VoteResolution
+	t.Sealed = req.Sealed
+	t.MinBet = req.MinBet
+	t.MaxBet = req.MaxBet
+	t.MaxBankrollPercent = req.MaxBankrollPercent
+	t.Tags = types.NormalizeTags(req.Tags)
+}
+
+func (h *Handler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates := h.Store.ListTemplates()
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+
+	h.jsonResponse(w, http.StatusOK, templates)
+}
+
+func (h *Handler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	var req TemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if problem := req.problem(); problem != "" {
+		h.errorResponse(w, http.StatusBadRequest, problem)
+		return
+	}
+
+	templateID, err := repo.NewID()
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to generate template ID")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	template := types.PredictionTemplate{
+		ID:        templateID,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	req.apply(&template)
+
+	if err := h.Store.PutTemplate(template); err != nil {
+		h.Logger.WithError(err).Error("failed to create template")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusCreated, template)
+}
+
+func (h *Handler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	template, err := h.Store.GetTemplate(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Template not found")
+		return
+	}
+
+	var req TemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if problem := req.problem(); problem != "" {
+		h.errorResponse(w, http.StatusBadRequest, problem)
+		return
+	}
+
+	req.apply(&template)
+
+	if err := h.Store.PutTemplate(template); err != nil {
+		h.Logger.WithError(err).Error("failed to update template")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, template)
+}
+
+func (h *Handler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	err := h.Store.DeleteTemplate(id)
+	if err == repo.ErrTemplateNotFound {
+		h.errorResponse(w, http.StatusNotFound, "Template not found")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to delete template")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// NewPredictionFromRequest is used when instantiating a template or cloning a prediction.
+type NewPredictionFromRequest struct {
+	// ClosesInSeconds overrides how long the new prediction stays open, counted from OpensAt if set, otherwise from now.
+	// 0 means it doesn't close automatically. If not set, the template's duration or the cloned prediction's duration is used.
+	ClosesInSeconds *int64 `json:"closes_in_seconds"`
+	OpensAt         string `json:"opens_at"`
+	// OccasionID puts the new prediction in an occasion. If not set, a clone stays in the original's occasion.
+	OccasionID *string `json:"occasion_id"`
+}
+
+func (req NewPredictionFromRequest) closesIn(fallback time.Duration) time.Duration {
+	if req.ClosesInSeconds == nil {
+		return fallback
+	}
+	return time.Duration(*req.ClosesInSeconds) * time.Second
+}
+
+func (h *Handler) decodeNewPredictionFromRequest(w http.ResponseWriter, r *http.Request) (NewPredictionFromRequest, bool) {
+	var req NewPredictionFromRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return req, false
+	}
+
+	if req.ClosesInSeconds != nil && *req.ClosesInSeconds < 0 {
+		h.errorResponse(w, http.StatusBadRequest, "closes_in_seconds can't be negative")
+		return req, false
+	}
+
+	if req.OccasionID != nil && *req.OccasionID != "" {
+		if _, err := h.Store.GetOccasion(*req.OccasionID); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Occasion not found")
+			return req, false
+		}
+	}
+
+	return req, true
+}
+
+func (h *Handler) InstantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	template, err := h.Store.GetTemplate(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Template not found")
+		return
+	}
+
+	req, ok := h.decodeNewPredictionFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	status, closesAt, err := scheduleNewPrediction(req.OpensAt, req.closesIn(time.Duration(template.DurationSeconds)*time.Second))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid opens_at")
+		return
+	}
+
+	predictionID, err := repo.NewID()
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to generate prediction ID")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	choices, err := newChoices(template.Choices)
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to generate choice ID")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	prediction := types.Prediction{
+		ID:                   predictionID,
+		CreatedAt:            time.Now().Format(time.RFC3339),
+		Name:                 template.Name,
+		Description:          template.Description,
+		Status:               status,
+		OpensAt:              req.OpensAt,
+		ClosesAt:             closesAt,
+		Choices:              choices,
+		OddsVisibleBeforeBet: template.OddsVisibleBeforeBet,
+		AntiSnipe:            template.AntiSnipe,
+		VoteResolution:       template.VoteResolution,
+		Sealed:               template.Sealed,
+		MinBet:               template.MinBet,
+		MaxBet:               template.MaxBet,
+		MaxBankrollPercent:   template.MaxBankrollPercent,
+		Tags:                 template.Tags,
+	}
+	if req.OccasionID != nil {
+		prediction.OccasionID = *req.OccasionID
+	}
+
+	h.storeNewPrediction(w, prediction)
+}
+
+func (h *Handler) ClonePrediction(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	original, err := h.Store.GetPrediction(id)
+	if err == repo.ErrPredictionNotFound {
+		// archived predictions can be cloned too
+		archived, archivedErr := h.Store.GetArchivedPredictionWithOdds(id)
+		original, err = archived.Prediction, archivedErr
+	}
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+		return
+	}
+
+	req, ok := h.decodeNewPredictionFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// by default, the clone stays open as long as the original was originally meant to
+	var originalDuration time.Duration
+	if original.ClosesAt != "" {
+		start := original.CreatedAt
+		if original.OpensAt != "" {
+			start = original.OpensAt
+		}
+		startedAt, startErr := time.Parse(time.RFC3339, start)
+		closesAt, closesErr := time.Parse(time.RFC3339, original.ClosesAt)
+		if startErr == nil && closesErr == nil {
+			originalDuration = closesAt.Sub(startedAt) - time.Duration(original.ClosesAtExtendedSeconds)*time.Second
+		}
+	}
+
+	status, closesAt, err := scheduleNewPrediction(req.OpensAt, req.closesIn(originalDuration))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid opens_at")
+		return
+	}
+
+	predictionID, err := repo.NewID()
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to generate prediction ID")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	choiceNames := make([]string, 0, len(original.Choices))
+	for _, c := range original.Choices {
+		choiceNames = append(choiceNames, c.Name)
+	}
+	choices, err := newChoices(choiceNames)
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to generate choice ID")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	prediction := types.Prediction{
+		ID:                   predictionID,
+		CreatedAt:            time.Now().Format(time.RFC3339),
+		Name:                 original.Name,
+		Description:          original.Description,
+		Status:               status,
+		OpensAt:              req.OpensAt,
+		ClosesAt:             closesAt,
+		Choices:              choices,
+		OddsVisibleBeforeBet: original.OddsVisibleBeforeBet,
+		AntiSnipe:            original.AntiSnipe,
+		VoteResolution:       original.VoteResolution,
+		Sealed:               original.Sealed,
+		MinBet:               original.MinBet,
+		MaxBet:               original.MaxBet,
+		MaxBankrollPercent:   original.MaxBankrollPercent,
+		OccasionID:           original.OccasionID,
+		Tags:                 original.Tags,
+	}
+	if req.OccasionID != nil {
+		prediction.OccasionID = *req.OccasionID
+	}
+
+	h.storeNewPrediction(w, prediction)
+}
+
+type ArchivePredictionsRequest struct {
+	// PredictionIDs limits archiving to these predictions. If empty, every finished prediction is considered.
+	PredictionIDs []string `json:"prediction_ids"`
+	// OlderThanHours limits archiving to predictions finished at least this long ago.
+	OlderThanHours int64 `json:"older_than_hours"`
+}
+
+func (h *Handler) ArchivePredictions(w http.ResponseWriter, r *http.Request) {
+	var req ArchivePredictionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.OlderThanHours < 0 {
+		h.errorResponse(w, http.StatusBadRequest, "older_than_hours can't be negative")
+		return
+	}
+
+	var finishedBefore time.Time
+	if req.OlderThanHours > 0 {
+		finishedBefore = time.Now().Add(-time.Duration(req.OlderThanHours) * time.Hour)
+	}
+
+	archived, err := h.Store.ArchivePredictions(req.PredictionIDs, finishedBefore)
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to archive predictions")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	if archived > 0 {
+		h.EventHub.EmitPredictions()
+		h.EventHub.EmitBetsAll()
+	}
+
+	h.jsonResponse(w, http.StatusOK, map[string]int{"archived": archived})
+}
+
+// Sweep closes any open predictions whose ClosesAt time has passed,
+// opens scheduled predictions whose OpensAt time has passed,
+// and opens or voids conditional predictions whose parent has been decided.
+func (h *Handler) Sweep() {
+	h.changeMu.Lock()
+	defer h.changeMu.Unlock()
+	h.sweep()
+}
+
+// sweep is Sweep for handlers that already hold changeMu (or make changes that aren't audited).
+func (h *Handler) sweep() {
+	events := &pendingEvents{}
+	h.sweepInto(events)
+	h.emitEvents(events)
+}
+
+// sweepInto is sweep, adding its events to events instead of sending them.
+func (h *Handler) sweepInto(events *pendingEvents) {
+	now := time.Now()
+	predictions := h.Store.ListPredictions()
+	// deadlines are paused while the market is frozen, Thaw pushes them back afterwards
+	frozen := h.Store.IsFrozen()
+
+	statuses := make(map[string]types.Prediction, len(predictions))
+	for _, p := range predictions {
+		statuses[p.ID] = p
+	}
+
+	opensAtPassed := func(p types.Prediction) bool {
+		if p.OpensAt == "" {
+			return true
+		}
+		opensAt, err := time.Parse(time.RFC3339, p.OpensAt)
+		if err != nil {
+			h.Logger.WithError(err).WithField("prediction_id", p.ID).Warn("failed to parse opens_at")
+			return false
+		}
+		return !now.Before(opensAt)
+	}
+
+	opened, voided := 0, 0
+	for _, p := range predictions {
+		if frozen || p.Status != types.PredictionStatusScheduled || !opensAtPassed(p) {
+			continue
+		}
+		if err := h.Store.OpenPrediction(p.ID); err != nil {
+			h.Logger.WithError(err).WithField("prediction_id", p.ID).Warn("sweep: failed to open scheduled prediction")
+			continue
+		}
+		h.Logger.WithField("prediction_id", p.ID).Info("sweep: opened scheduled prediction")
+		opened++
+	}
+	for _, p := range predictions {
+		if p.Status != types.PredictionStatusPending || p.ParentPredictionID == "" {
+			continue
+		}
+		parent, ok := statuses[p.ParentPredictionID]
+		if ok && parent.Status != types.PredictionStatusDecided && parent.Status != types.PredictionStatusVoid {
+			continue // parent still undecided
+		}
+		if ok && parent.Status == types.PredictionStatusDecided && parent.WinningChoiceID == p.ParentChoiceID {
+			if frozen || !opensAtPassed(p) {
+				continue // parent went the right way, but it isn't time yet (or the clock is paused)
+			}
+			if err := h.Store.OpenPrediction(p.ID); err != nil {
+				h.Logger.WithError(err).WithField("prediction_id", p.ID).Warn("sweep: failed to open conditional prediction")
+				continue
+			}
+			h.Logger.WithField("prediction_id", p.ID).Info("sweep: opened conditional prediction")
+			opened++
+			continue
+		}
+		// parent went the other way, was voided, or no longer exists
+		if err := h.Store.VoidPrediction(p.ID); err != nil {
+			h.Logger.WithError(err).WithField("prediction_id", p.ID).Warn("sweep: failed to void conditional prediction")
+			continue
+		}
+		h.Logger.WithField("prediction_id", p.ID).Info("sweep: voided conditional prediction")
+		voided++
+	}
+	if opened > 0 || voided > 0 {
+		events.predictions = true
+	}
+	if voided > 0 {
+		events.tokensChanged()
+	}
+
+	closed := 0
+	for _, p := range predictions {
+		if frozen || p.Status != types.PredictionStatusOpen || p.ClosesAt == "" {
+			continue
+		}
+		closesAt, err := time.Parse(time.RFC3339, p.ClosesAt)
+		if err != nil {
+			h.Logger.WithError(err).WithField("prediction_id", p.ID).Warn("failed to parse closes_at")
+			continue
+		}
+		if now.Before(closesAt) {
+			continue
+		}
+		if err := h.Store.ClosePrediction(p.ID); err != nil {
+			h.Logger.WithError(err).WithField("prediction_id", p.ID).Warn("sweep: failed to close prediction")
+			continue
+		}
+		h.Logger.WithField("prediction_id", p.ID).Info("sweep: closed prediction")
+		closed++
+		if p.Sealed {
+			events.reveals = append(events.reveals, p.ID)
+		}
+	}
+	if closed > 0 {
+		events.predictions = true
+	}
+
+	escalated := 0
+	for _, p := range predictions {
+		if p.Status != types.PredictionStatusClosed || p.VoteResolution == nil || p.VoteEscalated {
+			continue
+		}
+		if p.VoteResolution.VotingWindowSeconds == 0 || p.ClosedAt == "" {
+			continue
+		}
+		closedAt, err := time.Parse(time.RFC3339, p.ClosedAt)
+		if err != nil {
+			h.Logger.WithError(err).WithField("prediction_id", p.ID).Warn("failed to parse closed_at")
+			continue
+		}
+		if now.Before(closedAt.Add(time.Duration(p.VoteResolution.VotingWindowSeconds) * time.Second)) {
+			continue
+		}
+		if err := h.Store.EscalateResolutionVote(p.ID); err != nil {
+			h.Logger.WithError(err).WithField("prediction_id", p.ID).Warn("sweep: failed to escalate resolution vote")
+			continue
+		}
+		h.Logger.WithField("prediction_id", p.ID).Info("sweep: escalated resolution vote to admin")
+		escalated++
+	}
+	if escalated > 0 {
+		events.predictions = true
+	}
+
+	h.sweepHouseGames(now, frozen, events)
+	h.sweepDisputeWindows(now, predictions)
+	if !frozen {
+		h.sweepClosingSoon(now, predictions)
+	}
+}
+
+// sweepClosingSoon warns users who haven't bet on an open prediction as it passes each of ClosingSoonOffsets.
+// If several offsets pass between sweeps, only the nearest one is sent.
+func (h *Handler) sweepClosingSoon(now time.Time, predictions []types.Prediction) {
+	if len(h.ClosingSoonOffsets) == 0 {
+		return
+	}
+
+	h.closingSoonMu.Lock()
+	defer h.closingSoonMu.Unlock()
+
+	if h.closingSoonSent == nil {
+		h.closingSoonSent = map[string]closingSoonState{}
+	}
+
+	var users []types.User
+	open := map[string]struct{}{}
+	for _, p := range predictions {
+		if p.Status != types.PredictionStatusOpen || p.ClosesAt == "" {
+			continue
+		}
+		open[p.ID] = struct{}{}
+
+		closesAt, err := time.Parse(time.RFC3339, p.ClosesAt)
+		if err != nil {
+			continue // already logged by the close loop
+		}
+		remaining := closesAt.Sub(now)
+		if remaining <= 0 {
+			continue
+		}
+
+		state, ok := h.closingSoonSent[p.ID]
+		if !ok || state.closesAt != p.ClosesAt {
+			// deadline moved (or first time seeing this prediction), warnings start over
+			state = closingSoonState{closesAt: p.ClosesAt, sent: map[time.Duration]struct{}{}}
+			h.closingSoonSent[p.ID] = state
+		}
+
+		var nearest time.Duration
+		for _, offset := range h.ClosingSoonOffsets {
+			if remaining > offset {
+				continue
+			}
+			if _, sent := state.sent[offset]; sent {
+				continue
+			}
+			state.sent[offset] = struct{}{}
+			if nearest == 0 || offset < nearest {
+				nearest = offset
+			}
+		}
+		if nearest == 0 {
+			continue
+		}
+
+		if users == nil {
+			users = h.Store.ListUsers()
+		}
+		bettors := map[string]struct{}{}
+		for _, bet := range h.Store.ListBetsByPrediction(p.ID) {
+			bettors[bet.UserID] = struct{}{}
+		}
+		for _, u := range users {
+			if u.Admin {
+				continue
+			}
+			if _, ok := bettors[u.ID]; ok {
+				continue
+			}
+			h.EventHub.EmitClosingSoon(u.ID, p.ID, p.ClosesAt, int64(remaining.Seconds()))
+		}
+	}
+
+	for id := range h.closingSoonSent {
+		if _, ok := open[id]; !ok {
+			delete(h.closingSoonSent, id)
+		}
+	}
+}
+
+func (h *Handler) ClosePrediction(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	prediction, err := h.Store.GetPrediction(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+		return
+	}
+
+	err = h.Store.ClosePrediction(id)
+	if err == repo.ErrPredictionNotFound {
+		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+		return
+	}
+	if err == repo.ErrPredictionNotOpen {
+		h.errorResponse(w, http.StatusBadRequest, "Prediction is not open")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to close prediction")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.EventHub.EmitPredictions()
+	if prediction.Sealed {
+		h.EventHub.EmitReveal(id)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) ReopenPrediction(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	err := h.Store.ReopenPrediction(id)
+	if err == repo.ErrPredictionNotFound {
+		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+		return
+	}
+	if err == repo.ErrPredictionNotInClosedState {
+		h.errorResponse(w, http.StatusBadRequest, "Prediction is not closed")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to reopen prediction")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.EventHub.EmitPredictions()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) VoidPrediction(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if h.needsSecondAdmin(id) {
+		if h.requestOrConfirmDecision(w, r, id, types.PendingDecision{Action: types.DecisionActionVoid}) {
+			h.afterPredictionVoided()
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+
+	err := h.Store.VoidPrediction(id)
+	if err == repo.ErrPredictionNotFound {
+		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to void prediction")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.afterPredictionVoided()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pendingEvents collects the events a change needs, so each is only sent once however many predictions it touched.
+type pendingEvents struct {
+	predictions bool
+	leaderboard bool
+	bets        bool
+	parlays     bool
+	// reveals are sealed predictions that closed
+	reveals []string
+}
+
+// tokensChanged notes that bets were paid out or refunded.
+func (e *pendingEvents) tokensChanged() {
+	e.leaderboard = true
+	e.bets = true
+	e.parlays = true
+}
+
+func (h *Handler) emitEvents(events *pendingEvents) {
+	switch len(events.reveals) {
+	case 0:
+	case 1:
+		h.EventHub.EmitReveal(events.reveals[0])
+	default:
+		h.EventHub.EmitReveals(events.reveals)
+	}
+	if events.predictions {
+		h.EventHub.EmitPredictions()
+	}
+	if events.leaderboard {
+		h.EventHub.EmitLeaderboard()
+	}
+	if events.bets {
+		h.EventHub.EmitBetsAll()
+	}
+	if events.parlays {
+		h.EventHub.EmitParlaysAll()
+	}
+	*events = pendingEvents{}
+}
+
+// afterPredictionVoided notifies clients and opens or voids dependent predictions once a prediction has been voided.
+func (h *Handler) afterPredictionVoided() {
+	h.afterPredictionsVoided(&pendingEvents{})
+}
+
+// afterPredictionsVoided is afterPredictionVoided for several predictions, sending its events along with the ones
+// already in events.
+func (h *Handler) afterPredictionsVoided(events *pendingEvents) {
+	events.predictions = true
+	events.leaderboard = true
+	events.parlays = true
+
+	// open or void any conditional predictions waiting on these
+	h.sweepInto(events)
+	h.emitEvents(events)
+}
+
+type DecidePredictionRequest struct {
+	WinningChoiceID string `json:"winning_choice_id"`
+	// Salt reveals the commitment of a committed prediction
+	Salt string `json:"salt"`
+}
+
+func (h *Handler) DecidePrediction(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req DecidePredictionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if h.needsSecondAdmin(id) {
+		if h.requestOrConfirmDecision(w, r, id, types.PendingDecision{Action: types.DecisionActionDecide, WinningChoiceID: req.WinningChoiceID, Salt: req.Salt}) {
+			h.afterPredictionDecided(id)
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+
+	err := h.Store.DecidePrediction(id, req.WinningChoiceID, req.Salt)
+	if err == repo.ErrPredictionNotFound {
+		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+		return
+	}
+	if err == repo.ErrPredictionNotInClosedState {
+		h.errorResponse(w, http.StatusBadRequest, "Prediction must be closed to make decision")
+		return
+	}
+	if err == repo.ErrPredictionChoiceNotFound {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid winning choice")
+		return
+	}
+	if err == repo.ErrRevealRequired {
+		h.errorResponse(w, http.StatusBadRequest, "This prediction has a commitment, the salt is required to decide it")
+		return
+	}
+	if err == repo.ErrPredictionIsHouseGame {
+		h.errorResponse(w, http.StatusBadRequest, "House games are decided by the server")
+		return
+	}
+	if err == repo.ErrRevealMismatch {
+		h.errorResponse(w, http.StatusBadRequest, "Winning choice and salt don't match the commitment")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to decide prediction")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.afterPredictionDecided(id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// afterPredictionDecided notifies clients, opens or voids dependent predictions,
+// and hands out achievements and coins once a prediction has been decided.
+func (h *Handler) afterPredictionDecided(id string) {
+	h.afterPredictionsDecided([]string{id}, &pendingEvents{})
+}
+
+// afterPredictionsDecided is afterPredictionDecided for several predictions at once, sending its events along with
+// the ones already in events, once.
+func (h *Handler) afterPredictionsDecided(ids []string, events *pendingEvents) {
+	if h.DisputeWindow > 0 {
+		// released by Sweep once the dispute window is over
+		for _, id := range ids {
+			if err := h.Store.HoldDecisionRewards(id); err != nil {
+				h.Logger.WithError(err).WithField("prediction_id", id).Error("failed to hold decision rewards")
+			}
+		}
+	}
+
+	events.predictions = true
+	events.tokensChanged()
+
+	// open or void any conditional predictions waiting on these
+	h.sweepInto(events)
+	h.emitEvents(events)
+
+	if h.DisputeWindow <= 0 {
+		for _, id := range ids {
+			h.awardDecisionRewards(id)
+		}
+	}
+}
+
+// awardDecisionRewards checks achievements and awards coins for all users who had bets on a decided prediction.
+func (h *Handler) awardDecisionRewards(id string) {
+	bets := h.Store.ListBetsByPrediction(id)
+	for _, bet := range bets {
+		if bet.Status == types.BetStatusWon {
+			h.checkWinAchievements(bet.UserID, bet)
+			// Award coins: 1 coin per 200 tokens won
+			if coinsEarned := bet.WonAmount / 200; coinsEarned > 0 {
+				if err := h.Store.AddCoins(bet.UserID, coinsEarned); err != nil {
+					h.Logger.WithError(err).Error("failed to award bet win coins")
+				}
+			}
+		}
+		if bet.Status == types.BetStatusLost {
+			h.checkLossAchievements(bet.UserID, bet)
+		}
+		if bet.Status == types.BetStatusWon || bet.Status == types.BetStatusLost {
+			h.checkPostDecisionAchievements(bet.UserID)
+		}
+	}
+}
+
+// Dispute endpoints
+
+type FileDisputeRequest struct {
+	Note string `json:"note"`
+}
+
+func (h *Handler) FileDispute(w http.ResponseWriter, r *http.Request) {
+	user, _ := h.getAuthenticatedUser(r)
+	id := r.PathValue("id")
+
+	var req FileDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
 	}
-	// if len(req.Choices) > 0 {
-	// 	// Generate IDs for new choices
-	// 	for i := range req.Choices {
-	// 		if req.Choices[i].ID == "" {
-	// 			choiceID, _ := repo.NewID()
-	// 			req.Choices[i].ID = choiceID
-	// 		}
-	// 	}
-	// 	prediction.Choices = req.Choices
-	// }
-
-	err = h.Store.PutPrediction(prediction)
-	if err == repo.ErrPredictionNotOpen {
-		h.errorResponse(w, http.StatusBadRequest, "Can only update open predictions")
+
+	if req.Note == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Note is required")
 		return
 	}
+
+	disputeID, err := repo.NewID()
 	if err != nil {
-		h.Logger.WithError(err).Error("failed to update prediction")
+		h.Logger.WithError(err).Error("failed to generate dispute ID")
 		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
 	}
 
-	h.EventHub.EmitPredictions()
+	dispute, err := h.Store.AddDispute(types.Dispute{
+		ID:           disputeID,
+		CreatedAt:    time.Now().Format(time.RFC3339),
+		PredictionID: id,
+		UserID:       user.ID,
+		Note:         req.Note,
+	}, h.DisputeWindow)
+	switch err {
+	case nil:
+	case repo.ErrPredictionNotFound:
+		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+		return
+	case repo.ErrDisputeWindowClosed:
+		h.errorResponse(w, http.StatusBadRequest, "This prediction can't be disputed anymore")
+		return
+	case repo.ErrNotEligibleToDispute:
+		h.errorResponse(w, http.StatusForbidden, "Only players who bet on this prediction can dispute it")
+		return
+	case repo.ErrDisputeAlreadyOpen:
+		h.errorResponse(w, http.StatusBadRequest, "You already have an open dispute on this prediction")
+		return
+	case repo.ErrPredictionIsHouseGame:
+		h.errorResponse(w, http.StatusBadRequest, "House games are decided by a provably fair roll and can't be disputed")
+		return
+	default:
+		h.Logger.WithError(err).Error("failed to add dispute")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
 
-	h.jsonResponse(w, http.StatusOK, prediction)
+	h.EventHub.EmitDisputes()
+
+	h.jsonResponse(w, http.StatusCreated, dispute)
 }
 
-// Sweep closes any open predictions whose ClosesAt time has passed.
-func (h *Handler) Sweep() {
-	now := time.Now()
-	predictions := h.Store.ListPredictions()
-	closed := 0
-	for _, p := range predictions {
-		if p.Status != types.PredictionStatusOpen || p.ClosesAt == "" {
-			continue
-		}
-		closesAt, err := time.Parse(time.RFC3339, p.ClosesAt)
-		if err != nil {
-			h.Logger.WithError(err).WithField("prediction_id", p.ID).Warn("failed to parse closes_at")
-			continue
-		}
-		if now.Before(closesAt) {
-			continue
+func (h *Handler) ListDisputes(w http.ResponseWriter, r *http.Request) {
+	user, _ := h.getAuthenticatedUser(r)
+	statuses := r.URL.Query()["status"]
+
+	disputes := []types.Dispute{}
+	for _, d := range h.Store.ListDisputes() {
+		if !user.Admin {
+			// hosts only see disputes about their occasions
+			p, err := h.Store.GetPrediction(d.PredictionID)
+			if err != nil || !user.Can(types.PermissionDecidePredictions, p.OccasionID) {
+				continue
+			}
 		}
-		if err := h.Store.ClosePrediction(p.ID); err != nil {
-			h.Logger.WithError(err).WithField("prediction_id", p.ID).Warn("sweep: failed to close prediction")
-			continue
+		if len(statuses) > 0 {
+			hasStatus := false
+			for _, status := range statuses {
+				if d.Status == types.DisputeStatus(status) {
+					hasStatus = true
+					break
+				}
+			}
+			if !hasStatus {
+				continue
+			}
 		}
-		h.Logger.WithField("prediction_id", p.ID).Info("sweep: closed prediction")
-		closed++
-	}
-	if closed > 0 {
-		h.EventHub.EmitPredictions()
+		disputes = append(disputes, d)
 	}
+
+	// oldest first, so they're handled in order
+	sort.Slice(disputes, func(i, j int) bool {
+		return disputes[i].ID < disputes[j].ID
+	})
+
+	writePage(h, w, r, disputes, oldestFirst(func(d types.Dispute) string { return d.ID }))
 }
 
-func (h *Handler) ClosePrediction(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
+type ResolveDisputeRequest struct {
+	Note string `json:"note"`
+	// WinningChoiceID is the new winning choice when re-deciding
+	WinningChoiceID string `json:"winning_choice_id"`
+}
 
-	err := h.Store.ClosePrediction(id)
-	if err == repo.ErrPredictionNotFound {
+func (h *Handler) disputeErrorResponse(w http.ResponseWriter, err error) {
+	switch err {
+	case repo.ErrDisputeNotFound:
+		h.errorResponse(w, http.StatusNotFound, "Dispute not found")
+	case repo.ErrDisputeNotOpen:
+		h.errorResponse(w, http.StatusBadRequest, "Dispute has already been resolved")
+	case repo.ErrPredictionNotDecided:
+		h.errorResponse(w, http.StatusBadRequest, "Prediction is no longer decided")
+	case repo.ErrSameDecision:
+		h.errorResponse(w, http.StatusBadRequest, "Prediction is already decided that way, uphold the dispute instead")
+	case repo.ErrPredictionChoiceNotFound:
+		h.errorResponse(w, http.StatusBadRequest, "Invalid winning choice")
+	case repo.ErrTokensWouldBeNegative:
+		h.errorResponse(w, http.StatusConflict, "A winner has already spent their payout, it can't be taken back")
+	case repo.ErrRevealMismatch:
+		h.errorResponse(w, http.StatusBadRequest, "The host committed to the current answer, it can't be redecided")
+	case repo.ErrConditionalPredictionDecided:
+		h.errorResponse(w, http.StatusConflict, "A conditional prediction on this one has already been decided, it can't be redecided")
+	case repo.ErrPredictionNotFound:
 		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
-		return
+	case repo.ErrDecisionAlreadyPending:
+		h.errorResponse(w, http.StatusConflict, "A different decision is waiting for confirmation, cancel it first")
+	case repo.ErrDecisionNeedsDifferentAdmin:
+		h.errorResponse(w, http.StatusForbidden, "A different admin must confirm this decision")
+	default:
+		h.Logger.WithError(err).Error("failed to resolve dispute")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 	}
-	if err == repo.ErrPredictionNotOpen {
-		h.errorResponse(w, http.StatusBadRequest, "Prediction is not open")
+}
+
+func (h *Handler) UpholdDispute(w http.ResponseWriter, r *http.Request) {
+	admin, _ := h.getAuthenticatedUser(r)
+	id := r.PathValue("id")
+
+	var req ResolveDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+
+	dispute, err := h.Store.UpholdDispute(id, admin.ID, req.Note)
 	if err != nil {
-		h.Logger.WithError(err).Error("failed to close prediction")
-		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		h.disputeErrorResponse(w, err)
 		return
 	}
 
-	h.EventHub.EmitPredictions()
+	h.EventHub.EmitDisputes()
 
-	w.WriteHeader(http.StatusNoContent)
+	// the dispute may have been the only thing holding up rewards
+	h.sweep()
+
+	h.jsonResponse(w, http.StatusOK, dispute)
 }
 
-func (h *Handler) ReopenPrediction(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) RedecideDispute(w http.ResponseWriter, r *http.Request) {
+	admin, _ := h.getAuthenticatedUser(r)
 	id := r.PathValue("id")
 
-	err := h.Store.ReopenPrediction(id)
-	if err == repo.ErrPredictionNotFound {
-		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+	var req ResolveDisputeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
-	if err == repo.ErrPredictionNotInClosedState {
-		h.errorResponse(w, http.StatusBadRequest, "Prediction is not closed")
+
+	dispute, err := h.Store.GetDispute(id)
+	if err != nil {
+		h.disputeErrorResponse(w, err)
 		return
 	}
+
+	// taking back a big pool's payouts needs two admins, just like deciding it did
+	if h.needsSecondAdmin(dispute.PredictionID) {
+		d := types.PendingDecision{Action: types.DecisionActionRedecide, WinningChoiceID: req.WinningChoiceID, DisputeID: id, Note: req.Note}
+		applied, err := h.applyOrRequestDecision(admin.ID, dispute.PredictionID, &d)
+		if err != nil {
+			h.disputeErrorResponse(w, err)
+			return
+		}
+		if !applied {
+			h.EventHub.EmitPredictions()
+			h.jsonResponse(w, http.StatusAccepted, map[string]any{
+				"status":           "pending_confirmation",
+				"pending_decision": d,
+			})
+			return
+		}
+		dispute, err = h.Store.GetDispute(id)
+	} else {
+		dispute, err = h.Store.RedecideDispute(id, admin.ID, req.WinningChoiceID, req.Note)
+	}
 	if err != nil {
-		h.Logger.WithError(err).Error("failed to reopen prediction")
-		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		h.disputeErrorResponse(w, err)
 		return
 	}
 
+	h.EventHub.EmitDisputes()
+	h.afterPredictionDecided(dispute.PredictionID)
+
+	h.jsonResponse(w, http.StatusOK, dispute)
+}
+
+// sweepDisputeWindows hands out held achievements and coins once a prediction's dispute window is over
+// and it has no open disputes.
+func (h *Handler) sweepDisputeWindows(now time.Time, predictions []types.Prediction) {
+	for _, p := range predictions {
+		if p.Status != types.PredictionStatusDecided || !p.RewardsHeld {
+			continue
+		}
+		finishedAt, err := time.Parse(time.RFC3339, p.FinishedAt)
+		if err == nil && now.Before(finishedAt.Add(h.DisputeWindow)) {
+			continue
+		}
+		if h.Store.HasOpenDisputes(p.ID) {
+			continue
+		}
+		released, err := h.Store.ReleaseDecisionRewards(p.ID)
+		if err != nil {
+			h.Logger.WithError(err).WithField("prediction_id", p.ID).Warn("sweep: failed to release decision rewards")
+			continue
+		}
+		if released {
+			h.Logger.WithField("prediction_id", p.ID).Info("sweep: dispute window over, released decision rewards")
+			h.awardDecisionRewards(p.ID)
+		}
+	}
+}
+
+// Two-admin confirmation
+
+// needsSecondAdmin returns true if the prediction's pool is big enough that deciding or voiding it needs two admins.
+func (h *Handler) needsSecondAdmin(id string) bool {
+	if h.DecisionConfirmationThreshold <= 0 {
+		return false
+	}
+	p, err := h.Store.GetPredictionWithOdds(id)
+	if err != nil {
+		return false // let the regular path report it
+	}
+	return p.Odds.TotalTokensPlaced > h.DecisionConfirmationThreshold
+}
+
+func (h *Handler) decisionErrorResponse(w http.ResponseWriter, err error) {
+	switch err {
+	case repo.ErrPredictionNotFound:
+		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+	case repo.ErrPredictionNotInClosedState:
+		h.errorResponse(w, http.StatusBadRequest, "Prediction must be closed to make decision")
+	case repo.ErrPredictionChoiceNotFound:
+		h.errorResponse(w, http.StatusBadRequest, "Invalid winning choice")
+	case repo.ErrRevealRequired:
+		h.errorResponse(w, http.StatusBadRequest, "This prediction has a commitment, the salt is required to decide it")
+	case repo.ErrPredictionIsHouseGame:
+		h.errorResponse(w, http.StatusBadRequest, "House games are decided by the server")
+	case repo.ErrRevealMismatch:
+		h.errorResponse(w, http.StatusBadRequest, "Winning choice and salt don't match the commitment")
+	case repo.ErrDecisionAlreadyPending:
+		h.errorResponse(w, http.StatusConflict, "A different decision is waiting for confirmation, cancel it first")
+	case repo.ErrNoPendingDecision:
+		h.errorResponse(w, http.StatusBadRequest, "No decision is waiting for confirmation")
+	case repo.ErrDecisionNeedsDifferentAdmin:
+		h.errorResponse(w, http.StatusForbidden, "A different admin must confirm this decision")
+	case repo.ErrDisputeNotFound, repo.ErrDisputeNotOpen, repo.ErrPredictionNotDecided, repo.ErrSameDecision, repo.ErrTokensWouldBeNegative, repo.ErrConditionalPredictionDecided:
+		// a pending redecide
+		h.disputeErrorResponse(w, err)
+	default:
+		h.Logger.WithError(err).Error("failed to apply two-admin decision")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+	}
+}
+
+// requestOrConfirmDecision records the decision for a second admin to confirm, or confirms it if another admin already
+// requested the same decision. Returns true if the decision was applied.
+func (h *Handler) requestOrConfirmDecision(w http.ResponseWriter, r *http.Request, id string, d types.PendingDecision) bool {
+	admin, _ := h.getAuthenticatedUser(r)
+
+	applied, err := h.applyOrRequestDecision(admin.ID, id, &d)
+	if err != nil {
+		h.decisionErrorResponse(w, err)
+		return false
+	}
+	if applied {
+		return true
+	}
+
 	h.EventHub.EmitPredictions()
 
-	w.WriteHeader(http.StatusNoContent)
+	h.jsonResponse(w, http.StatusAccepted, map[string]any{
+		"status":           "pending_confirmation",
+		"pending_decision": d,
+	})
+	return false
 }
 
-func (h *Handler) VoidPrediction(w http.ResponseWriter, r *http.Request) {
+// applyOrRequestDecision confirms d if another admin already requested it, or records it as pending otherwise.
+// Returns true if the decision was applied.
+func (h *Handler) applyOrRequestDecision(adminID, id string, d *types.PendingDecision) (bool, error) {
+	prediction, err := h.Store.GetPrediction(id)
+	if err != nil {
+		return false, err
+	}
+
+	if prediction.PendingDecision != nil {
+		if !prediction.PendingDecision.Matches(*d) {
+			return false, repo.ErrDecisionAlreadyPending
+		}
+		if _, err := h.Store.ConfirmDecision(id, adminID); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	d.RequestedByUserID = adminID
+	d.RequestedAt = time.Now().Format(time.RFC3339)
+	return false, h.Store.RequestDecision(id, *d)
+}
+
+func (h *Handler) ConfirmDecision(w http.ResponseWriter, r *http.Request) {
+	admin, _ := h.getAuthenticatedUser(r)
 	id := r.PathValue("id")
 
-	err := h.Store.VoidPrediction(id)
-	if err == repo.ErrPredictionNotFound {
-		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+	decision, err := h.Store.ConfirmDecision(id, admin.ID)
+	if err != nil {
+		h.decisionErrorResponse(w, err)
 		return
 	}
-	if err != nil {
-		h.Logger.WithError(err).Error("failed to void prediction")
-		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+
+	switch decision.Action {
+	case types.DecisionActionVoid:
+		h.afterPredictionVoided()
+	case types.DecisionActionRedecide:
+		h.EventHub.EmitDisputes()
+		h.afterPredictionDecided(id)
+	default:
+		h.afterPredictionDecided(id)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) CancelPendingDecision(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if err := h.Store.CancelPendingDecision(id); err != nil {
+		h.decisionErrorResponse(w, err)
 		return
 	}
 
 	h.EventHub.EmitPredictions()
-	h.EventHub.EmitLeaderboard()
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-type DecidePredictionRequest struct {
-	WinningChoiceID string `json:"winning_choice_id"`
+// Resolution vote endpoints
+
+type CastResolutionVoteRequest struct {
+	ChoiceID string `json:"choice_id"`
 }
 
-func (h *Handler) DecidePrediction(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) CastResolutionVote(w http.ResponseWriter, r *http.Request) {
+	user, _ := h.getAuthenticatedUser(r)
 	id := r.PathValue("id")
 
-	var req DecidePredictionRequest
+	var req CastResolutionVoteRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
 
-	err := h.Store.DecidePrediction(id, req.WinningChoiceID)
-	if err == repo.ErrPredictionNotFound {
+	vote, winner, err := h.Store.CastResolutionVote(id, user.ID, req.ChoiceID)
+	switch err {
+	case nil:
+	case repo.ErrPredictionNotFound:
 		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
 		return
-	}
-	if err == repo.ErrPredictionNotInClosedState {
-		h.errorResponse(w, http.StatusBadRequest, "Prediction must be closed to make decision")
+	case repo.ErrPredictionNotVoteResolved:
+		h.errorResponse(w, http.StatusBadRequest, "This prediction isn't decided by vote")
 		return
-	}
-	if err == repo.ErrPredictionChoiceNotFound {
-		h.errorResponse(w, http.StatusBadRequest, "Invalid winning choice")
+	case repo.ErrPredictionNotInClosedState:
+		h.errorResponse(w, http.StatusBadRequest, "Voting is only possible once the prediction closes")
 		return
-	}
-	if err != nil {
-		h.Logger.WithError(err).Error("failed to decide prediction")
+	case repo.ErrResolutionVoteEscalated:
+		h.errorResponse(w, http.StatusBadRequest, "Voting is over, an admin will decide this prediction")
+		return
+	case repo.ErrPredictionChoiceNotFound:
+		h.errorResponse(w, http.StatusBadRequest, "Invalid choice")
+		return
+	case repo.ErrNotEligibleToVote:
+		h.errorResponse(w, http.StatusForbidden, "Only players without a stake in this prediction can vote")
+		return
+	case repo.ErrAlreadyVoted:
+		h.errorResponse(w, http.StatusBadRequest, "You have already voted")
+		return
+	default:
+		h.Logger.WithError(err).Error("failed to cast resolution vote")
 		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
 		return
 	}
 
-	h.EventHub.EmitPredictions()
-	h.EventHub.EmitLeaderboard()
-	h.EventHub.EmitBetsAll()
-
-	// Check achievements and award coins for all users who had bets on this prediction
-	bets := h.Store.ListBetsByPrediction(id)
-	for _, bet := range bets {
-		if bet.Status == types.BetStatusWon {
-			h.checkWinAchievements(bet.UserID, bet)
-			// Award coins: 1 coin per 200 tokens won
-			if coinsEarned := bet.WonAmount / 200; coinsEarned > 0 {
-				if err := h.Store.AddCoins(bet.UserID, coinsEarned); err != nil {
-					h.Logger.WithError(err).Error("failed to award bet win coins")
-				}
-			}
-		}
-		if bet.Status == types.BetStatusLost {
-			h.checkLossAchievements(bet.UserID, bet)
+	if winner != "" {
+		pending, err := h.Store.DecideByVote(id, winner, h.DecisionConfirmationThreshold)
+		if err == repo.ErrPredictionNotInClosedState || err == repo.ErrDecisionAlreadyPending {
+			// someone else's vote (or an admin) got there first
+		} else if err != nil {
+			h.Logger.WithError(err).WithField("prediction_id", id).Error("failed to decide prediction by vote")
+		} else if pending {
+			h.Logger.WithField("prediction_id", id).WithField("winning_choice_id", winner).Info("vote decision is waiting for an admin to confirm")
+			h.EventHub.EmitPredictions()
+		} else {
+			h.Logger.WithField("prediction_id", id).WithField("winning_choice_id", winner).Info("prediction decided by vote")
+			h.afterPredictionDecided(id)
 		}
-		if bet.Status == types.BetStatusWon || bet.Status == types.BetStatusLost {
-			h.checkPostDecisionAchievements(bet.UserID)
+	} else {
+		// vote counts changed, or the vote was escalated
+		h.EventHub.EmitPredictions()
+	}
+
+	h.jsonResponse(w, http.StatusCreated, vote)
+}
+
+type ResolutionVotesResponse struct {
+	Votes []types.ResolutionVote `json:"votes"`
+	// Tally maps choice IDs to their number of votes, leaving out superseded ones
+	Tally map[string]int64 `json:"tally"`
+}
+
+func (h *Handler) ListResolutionVotes(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if _, err := h.Store.GetPrediction(id); err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+		return
+	}
+
+	votes := h.Store.ListResolutionVotes(id)
+	sort.Slice(votes, func(i, j int) bool {
+		return votes[i].ID < votes[j].ID
+	})
+
+	tally := map[string]int64{}
+	for _, vote := range votes {
+		if !vote.Superseded {
+			tally[vote.ChoiceID]++
 		}
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	h.jsonResponse(w, http.StatusOK, ResolutionVotesResponse{Votes: votes, Tally: tally})
 }
 
 type GiftTokensRequest struct {
@@ -1486,6 +3780,12 @@ type GiftTokensRequest struct {
 func (h *Handler) GiftTokens(w http.ResponseWriter, r *http.Request) {
 	userID := r.PathValue("id")
 
+	caller, _ := h.getAuthenticatedUser(r)
+	if userID == caller.ID && !caller.Admin {
+		h.errorResponse(w, http.StatusForbidden, "You can't gift tokens to yourself")
+		return
+	}
+
 	var req GiftTokensRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
@@ -1516,6 +3816,40 @@ type ResetPINRequest struct {
 	NewPIN string `json:"new_pin"`
 }
 
+type SetUserAdminRequest struct {
+	Admin bool `json:"admin"`
+}
+
+func (h *Handler) SetUserAdmin(w http.ResponseWriter, r *http.Request) {
+	admin, _ := h.getAuthenticatedUser(r)
+	userID := r.PathValue("id")
+
+	var req SetUserAdminRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if userID == admin.ID {
+		// keeps at least one admin around
+		h.errorResponse(w, http.StatusBadRequest, "You can't change your own admin status")
+		return
+	}
+
+	err := h.Store.SetUserAdmin(userID, req.Admin)
+	if err == repo.ErrUserNotFound {
+		h.errorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to update user")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
 func (h *Handler) ResetPIN(w http.ResponseWriter, r *http.Request) {
 	userID := r.PathValue("id")
 
@@ -1613,12 +3947,18 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	// Public
 	mux.HandleFunc("GET /api/predictions", h.ListPredictions)
 	mux.HandleFunc("GET /api/predictions/{id}", h.GetPrediction)
+	mux.HandleFunc("GET /api/predictions/{id}/votes", h.ListResolutionVotes)
 	mux.HandleFunc("GET /api/leaderboard", h.ShowLeaderboard)
+	mux.HandleFunc("GET /api/occasions", h.ListOccasions)
+	mux.HandleFunc("GET /api/archive/predictions", h.ListArchivedPredictions)
+	mux.HandleFunc("GET /api/archive/predictions/{id}", h.GetArchivedPrediction)
 	mux.HandleFunc("GET /api/achievements", h.GetAchievements)
+	mux.HandleFunc("GET /api/freeze", h.GetFreeze)
 
 	// Guest
 	mux.HandleFunc("POST /api/register", h.Register)
 	mux.HandleFunc("POST /api/login", h.Login)
+	mux.HandleFunc("POST /api/oracles/{id}/webhook", h.OracleWebhook)
 
 	// User (authenticated)
 	mux.HandleFunc("GET /api/me", h.requireAuth(h.GetMe))
@@ -1626,22 +3966,64 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/my-achievements", h.requireAuth(h.GetMyAchievements))
 	mux.HandleFunc("POST /api/spin", h.requireAuth(h.Spin))
 	mux.HandleFunc("GET /api/shop", h.ListShopItems)
-	mux.HandleFunc("POST /api/shop/buy/{itemId}", h.requireAuth(h.BuyShopItem))
+	mux.HandleFunc("POST /api/shop/buy/{itemId}", h.requireAuth(h.rejectWhileFrozen(h.BuyShopItem)))
 	mux.HandleFunc("PUT /api/shop/equip/{itemId}", h.requireAuth(h.EquipItem))
 	mux.HandleFunc("DELETE /api/shop/equip/{category}", h.requireAuth(h.UnequipCategory))
-	mux.HandleFunc("POST /api/bets", h.requireAuth(h.PlaceBet))
-	mux.HandleFunc("PUT /api/bets/{id}/amount", h.requireAuth(h.IncreaseBetAmount))
-	mux.HandleFunc("POST /api/minigame/claim", h.requireAuth(h.ClaimMinigameCoins))
+	mux.HandleFunc("POST /api/bets", h.requireAuth(h.rejectWhileFrozen(h.PlaceBet)))
+	mux.HandleFunc("PUT /api/bets/{id}/amount", h.requireAuth(h.rejectWhileFrozen(h.IncreaseBetAmount)))
+	mux.HandleFunc("GET /api/my-parlays", h.requireAuth(h.GetMyParlays))
+	mux.HandleFunc("GET /api/archive/my-bets", h.requireAuth(h.GetMyArchivedBets))
+	mux.HandleFunc("POST /api/parlays", h.requireAuth(h.rejectWhileFrozen(h.PlaceParlay)))
+	mux.HandleFunc("POST /api/proposals", h.requireAuth(h.ProposePrediction))
+	mux.HandleFunc("POST /api/predictions/{id}/votes", h.requireAuth(h.CastResolutionVote))
+	mux.HandleFunc("POST /api/predictions/{id}/disputes", h.requireAuth(h.FileDispute))
+	mux.HandleFunc("GET /api/my-proposals", h.requireAuth(h.GetMyProposals))
+	mux.HandleFunc("POST /api/minigame/claim", h.requireAuth(h.rejectWhileFrozen(h.ClaimMinigameCoins)))
 	mux.HandleFunc("GET /api/minigame/leaderboard", h.MinigameLeaderboard)
 
-	// Admin
-	mux.HandleFunc("GET /api/admin/users", h.requireAdmin(h.ListUsers))
-	mux.HandleFunc("POST /api/admin/predictions", h.requireAdmin(h.CreatePrediction))
-	mux.HandleFunc("PUT /api/admin/predictions/{id}", h.requireAdmin(h.UpdatePrediction))
-	mux.HandleFunc("POST /api/admin/predictions/{id}/close", h.requireAdmin(h.ClosePrediction))
-	mux.HandleFunc("POST /api/admin/predictions/{id}/reopen", h.requireAdmin(h.ReopenPrediction))
-	mux.HandleFunc("POST /api/admin/predictions/{id}/void", h.requireAdmin(h.VoidPrediction))
-	mux.HandleFunc("POST /api/admin/predictions/{id}/decide", h.requireAdmin(h.DecidePrediction))
-	mux.HandleFunc("POST /api/admin/users/{id}/tokens", h.requireAdmin(h.GiftTokens))
+	// Admin (or staff with a role granting the permission, see roles.go)
+	manage := h.requirePermission(types.PermissionManagePredictions)
+	decide := h.requirePermission(types.PermissionDecidePredictions)
+	manageOrDecide := h.requirePermission(types.PermissionManagePredictions, types.PermissionDecidePredictions)
+	gift := h.requirePermission(types.PermissionGiftTokens)
+	mux.HandleFunc("GET /api/admin/users", gift(h.ListUsers))
+	mux.HandleFunc("GET /api/admin/bets", h.requireAdmin(h.ListBets))
+	mux.HandleFunc("GET /api/admin/audit", h.requireAdmin(h.ListAuditEntries))
+	mux.HandleFunc("POST /api/admin/archive", h.requireAdmin(h.ArchivePredictions))
+	mux.HandleFunc("POST /api/admin/freeze", h.requireAdmin(h.FreezeMarket))
+	mux.HandleFunc("POST /api/admin/thaw", h.requireAdmin(h.ThawMarket))
+	mux.HandleFunc("POST /api/admin/occasions", manage(h.CreateOccasion))
+	mux.HandleFunc("PUT /api/admin/occasions/{id}", manage(h.UpdateOccasion))
+	mux.HandleFunc("POST /api/admin/predictions", manage(h.CreatePrediction))
+	mux.HandleFunc("POST /api/admin/predictions/import", manage(h.ImportPredictions))
+	mux.HandleFunc("GET /api/admin/predictions/export", manage(h.ExportPredictions))
+	mux.HandleFunc("POST /api/admin/predictions/batch", manageOrDecide(h.BatchPredictions))
+	mux.HandleFunc("PUT /api/admin/predictions/{id}", manage(h.UpdatePrediction))
+	mux.HandleFunc("POST /api/admin/predictions/{id}/clone", manage(h.ClonePrediction))
+	mux.HandleFunc("GET /api/admin/disputes", decide(h.ListDisputes))
+	mux.HandleFunc("POST /api/admin/disputes/{id}/uphold", decide(h.UpholdDispute))
+	mux.HandleFunc("POST /api/admin/disputes/{id}/redecide", decide(h.RedecideDispute))
+	mux.HandleFunc("GET /api/admin/proposals", manage(h.ListProposals))
+	mux.HandleFunc("POST /api/admin/proposals/{id}/approve", manage(h.ApproveProposal))
+	mux.HandleFunc("POST /api/admin/proposals/{id}/reject", manage(h.RejectProposal))
+	mux.HandleFunc("POST /api/admin/proposals/{id}/merge", manage(h.MergeProposal))
+	mux.HandleFunc("GET /api/admin/templates", manage(h.ListTemplates))
+	mux.HandleFunc("POST /api/admin/templates", manage(h.CreateTemplate))
+	mux.HandleFunc("PUT /api/admin/templates/{id}", manage(h.UpdateTemplate))
+	mux.HandleFunc("DELETE /api/admin/templates/{id}", manage(h.DeleteTemplate))
+	mux.HandleFunc("POST /api/admin/templates/{id}/instantiate", manage(h.InstantiateTemplate))
+	mux.HandleFunc("POST /api/admin/predictions/{id}/close", manage(h.ClosePrediction))
+	mux.HandleFunc("POST /api/admin/predictions/{id}/reopen", manage(h.ReopenPrediction))
+	mux.HandleFunc("POST /api/admin/predictions/{id}/void", decide(h.VoidPrediction))
+	mux.HandleFunc("POST /api/admin/predictions/{id}/decide", decide(h.DecidePrediction))
+	mux.HandleFunc("POST /api/admin/predictions/{id}/confirm", decide(h.ConfirmDecision))
+	mux.HandleFunc("DELETE /api/admin/predictions/{id}/pending-decision", decide(h.CancelPendingDecision))
+	mux.HandleFunc("GET /api/admin/predictions/{id}/oracle", decide(h.GetOracle))
+	mux.HandleFunc("PUT /api/admin/predictions/{id}/oracle", decide(h.PutOracle))
+	mux.HandleFunc("DELETE /api/admin/predictions/{id}/oracle", decide(h.DeleteOracle))
+	mux.HandleFunc("POST /api/admin/predictions/{id}/oracle/test", decide(h.TestOracle))
+	mux.HandleFunc("POST /api/admin/users/{id}/tokens", gift(h.GiftTokens))
 	mux.HandleFunc("POST /api/admin/users/{id}/reset-pin", h.requireAdmin(h.ResetPIN))
+	mux.HandleFunc("PUT /api/admin/users/{id}/admin", h.requireAdmin(h.SetUserAdmin))
+	mux.HandleFunc("PUT /api/admin/users/{id}/roles", h.requireAdmin(h.SetUserRoles))
 }
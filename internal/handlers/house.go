@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/repo"
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// House games keep the market going between human-driven questions: Sweep rolls closed rounds and starts new ones.
+
+// sweepHouseGames decides house game rounds that have closed, then starts new rounds of HouseGames that are due
+// (unless the market is frozen).
+func (h *Handler) sweepHouseGames(now time.Time, frozen bool, events *pendingEvents) {
+	// closing happens earlier in the sweep, so look at fresh statuses
+	for _, p := range h.Store.ListPredictions() {
+		if p.HouseGame == nil || p.Status != types.PredictionStatusClosed {
+			continue
+		}
+		round, err := h.Store.DecideHouseGameRound(p.ID)
+		if err != nil {
+			h.Logger.WithError(err).WithField("prediction_id", p.ID).Warn("sweep: failed to roll house game")
+			continue
+		}
+		h.Logger.WithField("prediction_id", p.ID).WithField("roll", *round.Roll).Info("sweep: rolled house game")
+
+		// not afterPredictionDecided: that sweeps again, and rolls can't be disputed so rewards aren't held
+		events.predictions = true
+		events.tokensChanged()
+		h.awardDecisionRewards(p.ID)
+	}
+
+	if len(h.HouseGames) == 0 || frozen {
+		return
+	}
+
+	// Sweep runs from the ticker and from handlers, only one of them should start rounds
+	h.houseGamesMu.Lock()
+	defer h.houseGamesMu.Unlock()
+
+	latest := h.Store.LatestHouseGameRounds()
+	started := 0
+	for _, kind := range h.HouseGames {
+		round, exists := latest[kind]
+		if !houseGameRoundDue(round, exists, h.HouseGameInterval, now) {
+			continue
+		}
+		id, err := h.startHouseGameRound(kind, now)
+		if err != nil {
+			h.Logger.WithError(err).WithField("house_game", kind).Warn("sweep: failed to start house game round")
+			continue
+		}
+		h.Logger.WithField("prediction_id", id).WithField("house_game", kind).Info("sweep: started house game round")
+		started++
+	}
+	if started > 0 {
+		events.predictions = true
+	}
+}
+
+// houseGameRoundDue returns true if a new round should start, given the kind's latest round.
+func houseGameRoundDue(latest types.Prediction, exists bool, interval time.Duration, now time.Time) bool {
+	if !exists {
+		return true
+	}
+	if latest.Status == types.PredictionStatusOpen || latest.Status == types.PredictionStatusClosed {
+		return false // still running
+	}
+	createdAt, err := time.Parse(time.RFC3339, latest.CreatedAt)
+	if err != nil {
+		return true
+	}
+	return !now.Before(createdAt.Add(interval))
+}
+
+func (h *Handler) startHouseGameRound(kind types.HouseGameKind, now time.Time) (string, error) {
+	predictionID, err := repo.NewID()
+	if err != nil {
+		return "", err
+	}
+	choices, err := newChoices(kind.ChoiceNames())
+	if err != nil {
+		return "", err
+	}
+
+	seedBytes := make([]byte, 32)
+	if _, err := rand.Read(seedBytes); err != nil {
+		return "", err
+	}
+	serverSeed := hex.EncodeToString(seedBytes)
+	seedHash := types.HouseGameSeedHash(serverSeed)
+
+	prediction := types.Prediction{
+		ID:        predictionID,
+		CreatedAt: now.Format(time.RFC3339),
+		Name:      kind.Title(),
+		Description: fmt.Sprintf(
+			"Rolled by the house at close. Server seed hash: %v. Once rolled, check that SHA-256(server seed) matches it, "+
+				"and that the roll is HMAC-SHA256(server seed, %v) as a big-endian uint64 from its first 8 bytes, modulo the number of outcomes.",
+			seedHash, predictionID,
+		),
+		Status:   types.PredictionStatusOpen,
+		ClosesAt: now.Add(h.HouseGameBetWindow).Format(time.RFC3339),
+		Choices:  choices,
+		Tags:     []string{"house"},
+		HouseGame: &types.HouseGameRound{
+			Kind:           kind,
+			ServerSeedHash: seedHash,
+		},
+	}
+
+	if err := h.Store.AddHouseGameRound(prediction, serverSeed); err != nil {
+		return "", err
+	}
+	return predictionID, nil
+}
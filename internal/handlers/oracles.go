@@ -0,0 +1,519 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/repo"
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// Oracles close and decide predictions from an external result, either pushed to a signed webhook:
+//
+//	POST /api/oracles/{prediction id}/webhook
+//	X-Oracle-Signature: sha256=<hex HMAC-SHA256 of the body, keyed with the oracle's secret>
+//
+//	{"result": "Chiefs", "final": true}
+//
+// or polled from a JSON endpoint. Either way, the oracle's Path picks the result out of the JSON document
+// ("result" above), and FinalPath, if set, says whether it's final ("final" above).
+
+const (
+	oracleSignatureHeader  = "X-Oracle-Signature"
+	oracleSignaturePrefix  = "sha256="
+	defaultOracleInterval  = 30 * time.Second
+	maxOracleDocumentBytes = 1 << 20
+)
+
+// oracleReport is what an oracle read out of a JSON document.
+type oracleReport struct {
+	Result   string `json:"result"`
+	Final    bool   `json:"final"`
+	ChoiceID string `json:"choice_id"`
+}
+
+type OracleRequest struct {
+	Kind            types.OracleKind  `json:"kind"`
+	Secret          string            `json:"secret"`
+	URL             string            `json:"url"`
+	IntervalSeconds int64             `json:"interval_seconds"`
+	Path            string            `json:"path"`
+	FinalPath       string            `json:"final_path"`
+	FinalValue      string            `json:"final_value"`
+	ChoiceValues    map[string]string `json:"choice_values"`
+}
+
+func (req OracleRequest) problem() string {
+	switch req.Kind {
+	case types.OracleKindWebhook:
+		if req.Secret == "" {
+			return "Webhook oracles need a secret"
+		}
+	case types.OracleKindPoll:
+		u, err := url.Parse(req.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "Poll oracles need an http or https URL"
+		}
+		if req.IntervalSeconds < 0 {
+			return "Poll interval must not be negative"
+		}
+	default:
+		return "Oracle kind must be webhook or poll"
+	}
+	if req.Path == "" {
+		return "Oracle path is required"
+	}
+	if _, err := parseJSONPath(req.Path); err != nil {
+		return "Invalid oracle path: " + err.Error()
+	}
+	if req.FinalPath != "" {
+		if _, err := parseJSONPath(req.FinalPath); err != nil {
+			return "Invalid oracle final path: " + err.Error()
+		}
+	}
+	return ""
+}
+
+func (h *Handler) GetOracle(w http.ResponseWriter, r *http.Request) {
+	oracle, err := h.Store.GetOracle(r.PathValue("id"))
+	if err == repo.ErrOracleNotFound {
+		h.errorResponse(w, http.StatusNotFound, "Oracle not found")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to get oracle")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, oracle)
+}
+
+func (h *Handler) PutOracle(w http.ResponseWriter, r *http.Request) {
+	var req OracleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if problem := req.problem(); problem != "" {
+		h.errorResponse(w, http.StatusBadRequest, problem)
+		return
+	}
+
+	oracle := types.Oracle{
+		PredictionID:    r.PathValue("id"),
+		Kind:            req.Kind,
+		Path:            req.Path,
+		FinalPath:       req.FinalPath,
+		FinalValue:      req.FinalValue,
+		ChoiceValues:    req.ChoiceValues,
+		Secret:          req.Secret,
+		URL:             req.URL,
+		IntervalSeconds: req.IntervalSeconds,
+	}
+	if oracle.Kind == types.OracleKindWebhook {
+		oracle.URL = ""
+		oracle.IntervalSeconds = 0
+	} else {
+		oracle.Secret = ""
+	}
+
+	oracle, err := h.Store.PutOracle(oracle)
+	if err == repo.ErrPredictionNotFound {
+		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+		return
+	}
+	if err == repo.ErrPredictionAlreadyFinished {
+		h.errorResponse(w, http.StatusBadRequest, "Prediction has already been decided or voided")
+		return
+	}
+	if err == repo.ErrPredictionChoiceNotFound {
+		h.errorResponse(w, http.StatusBadRequest, "Oracle choice values must map to choices of the prediction")
+		return
+	}
+	if err == repo.ErrPredictionCommitted {
+		h.errorResponse(w, http.StatusBadRequest, "Committed predictions are decided by the host, not by an oracle")
+		return
+	}
+	if err == repo.ErrPredictionIsHouseGame {
+		h.errorResponse(w, http.StatusBadRequest, "House games are decided by the server")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to put oracle")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, oracle)
+}
+
+func (h *Handler) DeleteOracle(w http.ResponseWriter, r *http.Request) {
+	err := h.Store.DeleteOracle(r.PathValue("id"))
+	if err == repo.ErrOracleNotFound {
+		h.errorResponse(w, http.StatusNotFound, "Oracle not found")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to delete oracle")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestOracle polls a poll oracle once and reports what it would decide, without deciding anything.
+func (h *Handler) TestOracle(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	oracle, err := h.Store.GetOracle(id)
+	if err == repo.ErrOracleNotFound {
+		h.errorResponse(w, http.StatusNotFound, "Oracle not found")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to get oracle")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+	if oracle.Kind != types.OracleKindPoll {
+		h.errorResponse(w, http.StatusBadRequest, "Only poll oracles can be tested")
+		return
+	}
+
+	prediction, err := h.Store.GetPrediction(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+		return
+	}
+
+	doc, err := h.fetchOracleDocument(r, oracle.URL)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	report, err := readOracleReport(oracle, prediction, doc)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, report)
+}
+
+// OracleWebhook accepts a signed result for a prediction with a webhook oracle.
+func (h *Handler) OracleWebhook(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	oracle, err := h.Store.GetOracle(id)
+	if err != nil || oracle.Kind != types.OracleKindWebhook {
+		h.errorResponse(w, http.StatusNotFound, "Oracle not found")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxOracleDocumentBytes))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !validOracleSignature(oracle.Secret, body, r.Header.Get(oracleSignatureHeader)) {
+		h.errorResponse(w, http.StatusUnauthorized, "Invalid signature")
+		return
+	}
+
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	report, err := h.applyOracleDocument(oracle, doc)
+	if err == repo.ErrPredictionNotFound {
+		h.errorResponse(w, http.StatusNotFound, "Prediction not found")
+		return
+	}
+	if err == repo.ErrPredictionNotInClosedState {
+		h.errorResponse(w, http.StatusConflict, "Prediction is not open or closed")
+		return
+	}
+	if err == repo.ErrDecisionAlreadyPending {
+		h.errorResponse(w, http.StatusConflict, "A decision is already waiting for an admin to confirm")
+		return
+	}
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, report)
+}
+
+func validOracleSignature(secret string, body []byte, header string) bool {
+	if !strings.HasPrefix(header, oracleSignaturePrefix) {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(header, oracleSignaturePrefix))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// PollOracles polls every due poll oracle of an open or closed prediction, deciding the predictions
+// whose oracles report a final result. main calls it on a ticker, next to Sweep.
+func (h *Handler) PollOracles() {
+	now := time.Now()
+	for _, oracle := range h.Store.ListOracles() {
+		if oracle.Kind != types.OracleKindPoll || !oracleDue(oracle, now) {
+			continue
+		}
+		prediction, err := h.Store.GetPrediction(oracle.PredictionID)
+		if err != nil {
+			continue // archived
+		}
+		if prediction.Status != types.PredictionStatusOpen && prediction.Status != types.PredictionStatusClosed {
+			continue
+		}
+		if prediction.PendingDecision != nil {
+			continue // waiting on an admin
+		}
+
+		doc, err := h.fetchOracleDocument(nil, oracle.URL)
+		if err != nil {
+			h.Logger.WithError(err).WithField("prediction_id", oracle.PredictionID).Warn("oracle: poll failed")
+			h.Store.RecordOracleCheck(oracle.PredictionID, oracle.LastResult, err.Error())
+			continue
+		}
+		if _, err := h.applyOracleDocument(oracle, doc); err != nil && err != repo.ErrPredictionNotInClosedState {
+			h.Logger.WithError(err).WithField("prediction_id", oracle.PredictionID).Warn("oracle: failed to apply poll result")
+		}
+	}
+}
+
+func oracleDue(oracle types.Oracle, now time.Time) bool {
+	if oracle.LastCheckedAt == "" {
+		return true
+	}
+	lastCheckedAt, err := time.Parse(time.RFC3339, oracle.LastCheckedAt)
+	if err != nil {
+		return true
+	}
+	interval := defaultOracleInterval
+	if oracle.IntervalSeconds > 0 {
+		interval = time.Duration(oracle.IntervalSeconds) * time.Second
+	}
+	return !now.Before(lastCheckedAt.Add(interval))
+}
+
+func (h *Handler) fetchOracleDocument(r *http.Request, url string) (any, error) {
+	ctx := h.GracefulCtx
+	if r != nil {
+		ctx = r.Context()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	client := h.OracleClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oracle endpoint responded %v", resp.Status)
+	}
+
+	var doc any
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOracleDocumentBytes)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("oracle endpoint did not respond with JSON: %w", err)
+	}
+	return doc, nil
+}
+
+// applyOracleDocument reads a result out of doc and, if it's final, closes and decides the prediction with it.
+func (h *Handler) applyOracleDocument(oracle types.Oracle, doc any) (oracleReport, error) {
+	h.changeMu.Lock()
+	defer h.changeMu.Unlock()
+
+	prediction, err := h.Store.GetPrediction(oracle.PredictionID)
+	if err != nil {
+		return oracleReport{}, err
+	}
+
+	report, err := readOracleReport(oracle, prediction, doc)
+	if err != nil {
+		h.Store.RecordOracleCheck(oracle.PredictionID, report.Result, err.Error())
+		return report, err
+	}
+	h.Store.RecordOracleCheck(oracle.PredictionID, report.Result, "")
+	if !report.Final {
+		return report, nil
+	}
+
+	closed, pending, err := h.Store.ResolveByOracle(oracle.PredictionID, report.ChoiceID, h.DecisionConfirmationThreshold)
+	if err != nil {
+		return report, err
+	}
+	if pending {
+		h.Logger.WithField("prediction_id", oracle.PredictionID).WithField("result", report.Result).Info("oracle: decision is waiting for an admin to confirm")
+		h.EventHub.EmitPredictions()
+		return report, nil
+	}
+
+	h.Logger.WithField("prediction_id", oracle.PredictionID).WithField("result", report.Result).Info("oracle: decided prediction")
+	if closed && prediction.Sealed {
+		h.EventHub.EmitReveal(prediction.ID)
+	}
+	h.afterPredictionDecided(prediction.ID)
+
+	return report, nil
+}
+
+func readOracleReport(oracle types.Oracle, prediction types.Prediction, doc any) (oracleReport, error) {
+	var report oracleReport
+
+	value, err := lookupJSONPath(doc, oracle.Path)
+	if err != nil {
+		return report, fmt.Errorf("oracle path %q: %w", oracle.Path, err)
+	}
+	report.Result = jsonScalarString(value)
+
+	if oracle.FinalPath != "" {
+		finalValue, err := lookupJSONPath(doc, oracle.FinalPath)
+		if err != nil {
+			return report, fmt.Errorf("oracle final path %q: %w", oracle.FinalPath, err)
+		}
+		want := oracle.FinalValue
+		if want == "" {
+			want = "true"
+		}
+		report.Final = strings.EqualFold(jsonScalarString(finalValue), want)
+	} else {
+		report.Final = report.Result != ""
+	}
+
+	if report.Result == "" {
+		return report, nil
+	}
+	report.ChoiceID = oracle.ChoiceFor(prediction, report.Result)
+	if report.ChoiceID == "" {
+		return report, fmt.Errorf("oracle result %q does not match any choice", report.Result)
+	}
+	return report, nil
+}
+
+func jsonScalarString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
+
+// JSONPath-style lookups: an optional leading "$", then .field, [index], or ["field"] segments.
+
+type jsonPathSegment struct {
+	key   string
+	index int
+	isKey bool
+}
+
+var errJSONPathNotFound = errors.New("no value at path")
+
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+
+	segments := []jsonPathSegment{}
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+			if end == 0 {
+				return nil, errors.New("empty field name")
+			}
+			segments = append(segments, jsonPathSegment{key: path[:end], isKey: true})
+			path = path[end:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end == -1 {
+				return nil, errors.New("unclosed [")
+			}
+			inner := strings.TrimSpace(path[1:end])
+			path = path[end+1:]
+			if len(inner) >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[len(inner)-1] == inner[0] {
+				segments = append(segments, jsonPathSegment{key: inner[1 : len(inner)-1], isKey: true})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid index %q", inner)
+			}
+			segments = append(segments, jsonPathSegment{index: index})
+		default:
+			if len(segments) > 0 {
+				return nil, fmt.Errorf("unexpected %q", path[0])
+			}
+			path = "." + path // a leading field name doesn't need a dot
+		}
+	}
+	return segments, nil
+}
+
+func lookupJSONPath(doc any, path string) (any, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	value := doc
+	for _, segment := range segments {
+		if segment.isKey {
+			object, ok := value.(map[string]any)
+			if !ok {
+				return nil, errJSONPathNotFound
+			}
+			if value, ok = object[segment.key]; !ok {
+				return nil, errJSONPathNotFound
+			}
+			continue
+		}
+		array, ok := value.([]any)
+		if !ok || segment.index >= len(array) {
+			return nil, errJSONPathNotFound
+		}
+		value = array[segment.index]
+	}
+	return value, nil
+}
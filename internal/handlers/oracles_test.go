@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.albinodrought.com/creamy-prediction-market/internal/events"
+	"go.albinodrought.com/creamy-prediction-market/internal/repo"
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+func newTestHandler(t *testing.T) *Handler {
+	t.Helper()
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	hub := events.NewHub()
+	go hub.Run()
+
+	return &Handler{
+		GracefulCtx: context.Background(),
+		Store:       repo.NewStore(),
+		Logger:      logger,
+		EventHub:    hub,
+	}
+}
+
+func TestPollOracles(t *testing.T) {
+	tests := []struct {
+		name       string
+		document   string
+		oracle     types.Oracle
+		wantStatus types.PredictionStatus
+		wantWinner string
+		wantResult string
+	}{
+		{
+			name:       "final result is mapped through choice values",
+			document:   `{"game": {"status": "final", "winner": "KC"}}`,
+			oracle:     types.Oracle{Path: "$.game.winner", FinalPath: "$.game.status", FinalValue: "final", ChoiceValues: map[string]string{"KC": "chiefs"}},
+			wantStatus: types.PredictionStatusDecided,
+			wantWinner: "chiefs",
+			wantResult: "KC",
+		},
+		{
+			name:       "non-final result leaves the prediction open",
+			document:   `{"game": {"status": "in_progress", "winner": "KC"}}`,
+			oracle:     types.Oracle{Path: "$.game.winner", FinalPath: "$.game.status", FinalValue: "final", ChoiceValues: map[string]string{"KC": "chiefs"}},
+			wantStatus: types.PredictionStatusOpen,
+			wantResult: "KC",
+		},
+		{
+			name:       "result without a choice value matches choice names ignoring case",
+			document:   `{"results": [{"name": "EAGLES"}]}`,
+			oracle:     types.Oracle{Path: "results[0].name"},
+			wantStatus: types.PredictionStatusDecided,
+			wantWinner: "eagles",
+			wantResult: "EAGLES",
+		},
+		{
+			name:       "empty result without a final path isn't final",
+			document:   `{"winner": ""}`,
+			oracle:     types.Oracle{Path: "winner"},
+			wantStatus: types.PredictionStatusOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, tt.document)
+			}))
+			defer server.Close()
+
+			h := newTestHandler(t)
+			h.OracleClient = server.Client()
+
+			prediction := types.Prediction{
+				ID:        "prediction",
+				Name:      "Who wins?",
+				Status:    types.PredictionStatusOpen,
+				CreatedAt: time.Now().Format(time.RFC3339),
+				Choices: []types.PredictionChoice{
+					{ID: "chiefs", Name: "Chiefs"},
+					{ID: "eagles", Name: "Eagles"},
+				},
+			}
+			if err := h.Store.PutPrediction(prediction); err != nil {
+				t.Fatalf("PutPrediction: %v", err)
+			}
+
+			oracle := tt.oracle
+			oracle.PredictionID = prediction.ID
+			oracle.Kind = types.OracleKindPoll
+			oracle.URL = server.URL
+			if _, err := h.Store.PutOracle(oracle); err != nil {
+				t.Fatalf("PutOracle: %v", err)
+			}
+
+			h.PollOracles()
+
+			got, err := h.Store.GetPrediction(prediction.ID)
+			if err != nil {
+				t.Fatalf("GetPrediction: %v", err)
+			}
+			if got.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", got.Status, tt.wantStatus)
+			}
+			if got.WinningChoiceID != tt.wantWinner {
+				t.Errorf("winning choice = %q, want %q", got.WinningChoiceID, tt.wantWinner)
+			}
+
+			checked, err := h.Store.GetOracle(prediction.ID)
+			if err != nil {
+				t.Fatalf("GetOracle: %v", err)
+			}
+			if checked.LastCheckedAt == "" {
+				t.Error("oracle wasn't marked as checked")
+			}
+			if checked.LastResult != tt.wantResult {
+				t.Errorf("last result = %q, want %q", checked.LastResult, tt.wantResult)
+			}
+			if checked.LastError != "" {
+				t.Errorf("last error = %q, want none", checked.LastError)
+			}
+		})
+	}
+}
+
+func TestValidOracleSignature(t *testing.T) {
+	secret := "shhh"
+	body := []byte(`{"result": "Chiefs", "final": true}`)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "good signature", header: "sha256=" + signature, want: true},
+		{name: "signed with another secret", header: "sha256=" + hex.EncodeToString(hmac.New(sha256.New, []byte("nope")).Sum(nil)), want: false},
+		{name: "bad signature", header: "sha256=" + signature[:len(signature)-2] + "00", want: false},
+		{name: "not hex", header: "sha256=zz", want: false},
+		{name: "missing prefix", header: signature, want: false},
+		{name: "missing header", header: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validOracleSignature(secret, body, tt.header); got != tt.want {
+				t.Errorf("validOracleSignature(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPollOraclesNeedsConfirmation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"winner": "Chiefs"}`)
+	}))
+	defer server.Close()
+
+	h := newTestHandler(t)
+	h.OracleClient = server.Client()
+	h.DecisionConfirmationThreshold = 100
+
+	prediction := types.Prediction{
+		ID:        "prediction",
+		Name:      "Who wins?",
+		Status:    types.PredictionStatusOpen,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		Choices: []types.PredictionChoice{
+			{ID: "chiefs", Name: "Chiefs"},
+			{ID: "eagles", Name: "Eagles"},
+		},
+	}
+	if err := h.Store.PutPrediction(prediction); err != nil {
+		t.Fatalf("PutPrediction: %v", err)
+	}
+	if err := h.Store.AddUser(types.User{ID: "player", Name: "Player"}, 1000); err != nil {
+		t.Fatalf("AddUser: %v", err)
+	}
+	if err := h.Store.CreateBet(types.Bet{ID: "bet", UserID: "player", PredictionID: prediction.ID, PredictionChoiceID: "chiefs", Amount: 500}); err != nil {
+		t.Fatalf("CreateBet: %v", err)
+	}
+	if _, err := h.Store.PutOracle(types.Oracle{PredictionID: prediction.ID, Kind: types.OracleKindPoll, URL: server.URL, Path: "winner"}); err != nil {
+		t.Fatalf("PutOracle: %v", err)
+	}
+
+	h.PollOracles()
+
+	got, err := h.Store.GetPrediction(prediction.ID)
+	if err != nil {
+		t.Fatalf("GetPrediction: %v", err)
+	}
+	if got.Status != types.PredictionStatusClosed {
+		t.Errorf("status = %q, want %q", got.Status, types.PredictionStatusClosed)
+	}
+	if got.PendingDecision == nil || got.PendingDecision.WinningChoiceID != "chiefs" || got.PendingDecision.RequestedByUserID != types.DecisionRequestedByOracle {
+		t.Fatalf("pending decision = %+v, want chiefs requested by the oracle", got.PendingDecision)
+	}
+
+	if _, err := h.Store.ConfirmDecision(prediction.ID, "admin"); err != nil {
+		t.Fatalf("ConfirmDecision: %v", err)
+	}
+	got, err = h.Store.GetPrediction(prediction.ID)
+	if err != nil {
+		t.Fatalf("GetPrediction: %v", err)
+	}
+	if got.Status != types.PredictionStatusDecided || got.WinningChoiceID != "chiefs" {
+		t.Errorf("status = %q, winning choice = %q, want decided with chiefs", got.Status, got.WinningChoiceID)
+	}
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// List endpoints return everything unless ?limit= is passed.
+// When there are more results, the cursor for the next page is returned in the X-Next-Cursor header,
+// and can be passed back as ?cursor=. Cursors are the (UUIDv7) ID of the last item on the previous page, or its
+// score and ID ("score:id") on leaderboards. The next page starts after that position, even if the item itself is gone.
+
+const maxPageLimit = 1000
+
+var errInvalidLimit = errors.New("invalid limit")
+var errInvalidCursor = errors.New("invalid cursor")
+var errInvalidTimeRange = errors.New("invalid since or until")
+
+type pageRequest struct {
+	limit  int
+	cursor string
+}
+
+func parsePageRequest(r *http.Request) (pageRequest, error) {
+	query := r.URL.Query()
+	page := pageRequest{cursor: query.Get("cursor")}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return page, errInvalidLimit
+		}
+		if limit > maxPageLimit {
+			limit = maxPageLimit
+		}
+		page.limit = limit
+	}
+	return page, nil
+}
+
+// pageOrder is how a list endpoint sorts its items, so paginate can tell which items come after a cursor.
+type pageOrder[T any] struct {
+	cursorOf func(T) string
+	// afterCursor returns a func reporting whether an item comes after the cursor, or an error if the cursor is malformed
+	afterCursor func(cursor string) (func(T) bool, error)
+}
+
+func parseIDCursor(cursor string) (string, error) {
+	if _, err := uuid.Parse(cursor); err != nil {
+		return "", errInvalidCursor
+	}
+	return cursor, nil
+}
+
+// oldestFirst is for lists sorted by ascending (UUIDv7) ID.
+func oldestFirst[T any](idOf func(T) string) pageOrder[T] {
+	return pageOrder[T]{
+		cursorOf: idOf,
+		afterCursor: func(cursor string) (func(T) bool, error) {
+			cursorID, err := parseIDCursor(cursor)
+			return func(item T) bool { return idOf(item) > cursorID }, err
+		},
+	}
+}
+
+// newestFirst is for lists sorted by descending (UUIDv7) ID.
+func newestFirst[T any](idOf func(T) string) pageOrder[T] {
+	return pageOrder[T]{
+		cursorOf: idOf,
+		afterCursor: func(cursor string) (func(T) bool, error) {
+			cursorID, err := parseIDCursor(cursor)
+			return func(item T) bool { return idOf(item) < cursorID }, err
+		},
+	}
+}
+
+// highestScoreFirst is for lists sorted by descending score, then ascending ID.
+func highestScoreFirst[T any](scoreOf func(T) int64, idOf func(T) string) pageOrder[T] {
+	return pageOrder[T]{
+		cursorOf: func(item T) string {
+			return strconv.FormatInt(scoreOf(item), 10) + ":" + idOf(item)
+		},
+		afterCursor: func(cursor string) (func(T) bool, error) {
+			rawScore, rawID, ok := strings.Cut(cursor, ":")
+			if !ok {
+				return nil, errInvalidCursor
+			}
+			cursorScore, err := strconv.ParseInt(rawScore, 10, 64)
+			if err != nil {
+				return nil, errInvalidCursor
+			}
+			cursorID, err := parseIDCursor(rawID)
+			return func(item T) bool {
+				score := scoreOf(item)
+				return score < cursorScore || (score == cursorScore && idOf(item) > cursorID)
+			}, err
+		},
+	}
+}
+
+// paginate returns the page of items (already in the endpoint's sort order) after the cursor,
+// setting X-Next-Cursor if more remain.
+func paginate[T any](w http.ResponseWriter, page pageRequest, items []T, order pageOrder[T]) ([]T, error) {
+	if page.cursor != "" {
+		after, err := order.afterCursor(page.cursor)
+		if err != nil {
+			return nil, err
+		}
+		start := len(items)
+		for i := range items {
+			if after(items[i]) {
+				start = i
+				break
+			}
+		}
+		items = items[start:]
+	}
+
+	if page.limit == 0 || len(items) <= page.limit {
+		return items, nil
+	}
+
+	items = items[:page.limit]
+	w.Header().Set("X-Next-Cursor", order.cursorOf(items[len(items)-1]))
+	return items, nil
+}
+
+// writePage paginates items and writes them, or a 400 if the page request was bad.
+func writePage[T any](h *Handler, w http.ResponseWriter, r *http.Request, items []T, order pageOrder[T]) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+	items, err = paginate(w, page, items, order)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid cursor")
+		return
+	}
+	h.jsonResponse(w, http.StatusOK, items)
+}
+
+type timeRange struct {
+	since time.Time
+	until time.Time
+}
+
+// parseTimeRange reads the optional ?since= and ?until= RFC3339 query params
+func parseTimeRange(r *http.Request) (timeRange, error) {
+	var tr timeRange
+	var err error
+	query := r.URL.Query()
+	if raw := query.Get("since"); raw != "" {
+		if tr.since, err = time.Parse(time.RFC3339, raw); err != nil {
+			return tr, errInvalidTimeRange
+		}
+	}
+	if raw := query.Get("until"); raw != "" {
+		if tr.until, err = time.Parse(time.RFC3339, raw); err != nil {
+			return tr, errInvalidTimeRange
+		}
+	}
+	return tr, nil
+}
+
+// contains reports whether an RFC3339 timestamp is within the range. Unparseable timestamps only match an open range.
+func (tr timeRange) contains(createdAt string) bool {
+	if tr.since.IsZero() && tr.until.IsZero() {
+		return true
+	}
+	t, err := time.Parse(time.RFC3339, createdAt)
+	if err != nil {
+		return false
+	}
+	if !tr.since.IsZero() && t.Before(tr.since) {
+		return false
+	}
+	if !tr.until.IsZero() && t.After(tr.until) {
+		return false
+	}
+	return true
+}
+
+// filterBets applies the ?user=, ?prediction=, ?status=, ?since= and ?until= query filters.
+// status may be repeated to match any of them.
+func filterBets(r *http.Request, bets []types.Bet) ([]types.Bet, error) {
+	tr, err := parseTimeRange(r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.URL.Query()
+	userID := query.Get("user")
+	predictionID := query.Get("prediction")
+	statuses := query["status"]
+
+	filtered := make([]types.Bet, 0, len(bets))
+	for _, bet := range bets {
+		if userID != "" && bet.UserID != userID {
+			continue
+		}
+		if predictionID != "" && bet.PredictionID != predictionID {
+			continue
+		}
+		if len(statuses) > 0 {
+			hasStatus := false
+			for _, status := range statuses {
+				if bet.Status == types.BetStatus(status) {
+					hasStatus = true
+					break
+				}
+			}
+			if !hasStatus {
+				continue
+			}
+		}
+		if !tr.contains(bet.CreatedAt) {
+			continue
+		}
+		filtered = append(filtered, bet)
+	}
+	return filtered, nil
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/repo"
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// Roles let admins hand out parts of their job: requirePermission guards the admin routes a role can use.
+
+// requirePermission returns a middleware that lets through admins and users with a role granting any of the
+// permissions, and audits what they change. On routes about a single prediction (or one of its disputes), hosts are
+// checked against the prediction's occasion.
+func (h *Handler) requirePermission(permissions ...types.Permission) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			user, ok := h.getAuthenticatedUser(r)
+			if !ok {
+				h.errorResponse(w, http.StatusUnauthorized, "Unauthorized")
+				return
+			}
+			if !h.permitted(user, permissions, r) {
+				h.errorResponse(w, http.StatusForbidden, "You don't have permission to do that")
+				return
+			}
+			h.audit(user, next)(w, r)
+		}
+	}
+}
+
+func (h *Handler) permitted(user types.User, permissions []types.Permission, r *http.Request) bool {
+	occasionID, scoped := h.permissionOccasion(r)
+	for _, permission := range permissions {
+		if scoped && user.Can(permission, occasionID) {
+			return true
+		}
+		if !scoped && user.CanSomewhere(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// permissionOccasion returns the occasion of the prediction the route is about, if it's about one.
+// Predictions that don't exist are left for the handler to report.
+func (h *Handler) permissionOccasion(r *http.Request) (string, bool) {
+	id := r.PathValue("id")
+	if id == "" {
+		return "", false
+	}
+
+	predictionID := ""
+	switch {
+	case strings.Contains(r.Pattern, "/api/admin/predictions/{id}"):
+		predictionID = id
+	case strings.Contains(r.Pattern, "/api/admin/disputes/{id}"):
+		dispute, err := h.Store.GetDispute(id)
+		if err != nil {
+			return "", false
+		}
+		predictionID = dispute.PredictionID
+	default:
+		return "", false
+	}
+
+	prediction, err := h.Store.GetPrediction(predictionID)
+	if err != nil {
+		return "", false
+	}
+	return prediction.OccasionID, true
+}
+
+type SetUserRolesRequest struct {
+	Roles []types.Role `json:"roles"`
+	// HostOccasionIDs are the occasions the user hosts, if they're given RoleHost
+	HostOccasionIDs []string `json:"host_occasion_ids"`
+}
+
+func (h *Handler) SetUserRoles(w http.ResponseWriter, r *http.Request) {
+	userID := r.PathValue("id")
+
+	var req SetUserRolesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	roles := []types.Role{}
+	host := false
+	for _, role := range req.Roles {
+		if !role.Valid() {
+			h.errorResponse(w, http.StatusBadRequest, "Roles must be moderator, banker or host")
+			return
+		}
+		if containsRole(roles, role) {
+			continue
+		}
+		roles = append(roles, role)
+		host = host || role == types.RoleHost
+	}
+
+	hostOccasionIDs := []string{}
+	for _, id := range req.HostOccasionIDs {
+		if !containsString(hostOccasionIDs, id) {
+			hostOccasionIDs = append(hostOccasionIDs, id)
+		}
+	}
+	if host && len(hostOccasionIDs) == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "Hosts need at least one occasion in host_occasion_ids")
+		return
+	}
+	if !host && len(hostOccasionIDs) > 0 {
+		h.errorResponse(w, http.StatusBadRequest, "Only hosts can have host_occasion_ids")
+		return
+	}
+
+	err := h.Store.SetUserRoles(userID, roles, hostOccasionIDs)
+	if err == repo.ErrUserNotFound {
+		h.errorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err == repo.ErrOccasionNotFound {
+		h.errorResponse(w, http.StatusBadRequest, "Occasion not found")
+		return
+	}
+	if err != nil {
+		h.Logger.WithError(err).Error("failed to update user roles")
+		h.errorResponse(w, http.StatusInternalServerError, "Internal Server Error")
+		return
+	}
+
+	h.jsonResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func containsRole(roles []types.Role, role types.Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
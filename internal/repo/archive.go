@@ -0,0 +1,225 @@
+package repo
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// The archive is cold storage for finished (decided or voided) predictions, their bets and their token logs.
+// Archived items are read-only: they're kept out of the hot maps so scans and Save don't pay for them,
+// and are persisted separately (gzip-compressed) with SaveArchive/LoadArchive.
+
+type archiveCopy struct {
+	Predictions map[string]types.Prediction
+	Bets        map[string]types.Bet
+	TokenLog    map[string]types.TokenLog
+}
+
+func newArchiveCopy() archiveCopy {
+	return archiveCopy{
+		Predictions: make(map[string]types.Prediction),
+		Bets:        make(map[string]types.Bet),
+		TokenLog:    make(map[string]types.TokenLog),
+	}
+}
+
+func (s *Store) SaveArchive(w io.Writer) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	gz := gzip.NewWriter(w)
+	if err := json.NewEncoder(gz).Encode(&s.archive); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	s.archiveDirty = false
+
+	return nil
+}
+
+func (s *Store) LoadArchive(r io.Reader) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	copy := newArchiveCopy()
+	if err := json.NewDecoder(gz).Decode(&copy); err != nil {
+		return err
+	}
+	if copy.Predictions == nil {
+		copy.Predictions = make(map[string]types.Prediction)
+	}
+	if copy.Bets == nil {
+		copy.Bets = make(map[string]types.Bet)
+	}
+	if copy.TokenLog == nil {
+		copy.TokenLog = make(map[string]types.TokenLog)
+	}
+
+	s.archiveDirty = false
+	s.archive = copy
+
+	return nil
+}
+
+func (s *Store) IsArchiveDirty() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.archiveDirty
+}
+
+// ArchivePredictions moves finished predictions, their bets and their token logs into the archive.
+// If ids is set, only those predictions are considered. If finishedBefore is set, only predictions finished before then are.
+// Predictions that a still-pending conditional prediction depends on are skipped.
+// Returns how many predictions were archived.
+func (s *Store) ArchivePredictions(ids []string, finishedBefore time.Time) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	candidates := ids
+	if len(candidates) == 0 {
+		for id := range s.predictions {
+			candidates = append(candidates, id)
+		}
+	}
+
+	parents := map[string]struct{}{}
+	for _, p := range s.predictions {
+		if p.ParentPredictionID != "" && p.Status == types.PredictionStatusPending {
+			parents[p.ParentPredictionID] = struct{}{}
+		}
+	}
+
+	archived := map[string]struct{}{}
+	for _, id := range candidates {
+		p, ok := s.predictions[id]
+		if !ok {
+			continue
+		}
+		if p.Status != types.PredictionStatusDecided && p.Status != types.PredictionStatusVoid {
+			continue
+		}
+		if _, ok := parents[id]; ok {
+			continue
+		}
+		if p.RewardsHeld {
+			continue // still in its dispute window
+		}
+		if !finishedBefore.IsZero() {
+			finishedAt := p.FinishedAt
+			if finishedAt == "" {
+				finishedAt = p.CreatedAt // finished before FinishedAt was tracked
+			}
+			t, err := time.Parse(time.RFC3339, finishedAt)
+			if err != nil || !t.Before(finishedBefore) {
+				continue
+			}
+		}
+		archived[id] = struct{}{}
+	}
+
+	if len(archived) == 0 {
+		return 0, nil
+	}
+
+	s.dirty = true
+	s.archiveDirty = true
+
+	for id := range archived {
+		s.archive.Predictions[id] = s.predictions[id]
+		delete(s.predictions, id)
+	}
+	for id, bet := range s.bets {
+		if _, ok := archived[bet.PredictionID]; !ok {
+			continue
+		}
+		if bet.Status == types.BetStatusLost {
+			// keep the leaderboard's loss forgiveness stable
+			s.archivedLosses[bet.UserID] += bet.Amount
+		}
+		s.archive.Bets[id] = bet
+		delete(s.bets, id)
+	}
+	for id, tc := range s.tokenLog {
+		if _, ok := archived[tc.PredictionID]; !ok {
+			continue
+		}
+		s.archive.TokenLog[id] = tc
+		delete(s.tokenLog, id)
+	}
+
+	return len(archived), nil
+}
+
+// ArchivedLostTokens is how many tokens the user lost on bets that have since been archived
+func (s *Store) ArchivedLostTokens(userID string) int64 {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.archivedLosses[userID]
+}
+
+func (s *Store) GetArchivedPredictionWithOdds(id string) (types.PredictionWithOdds, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	prediction, ok := s.archive.Predictions[id]
+	if !ok {
+		return types.PredictionWithOdds{}, ErrPredictionNotFound
+	}
+
+	bets := make([]types.Bet, 0)
+	for _, bet := range s.archive.Bets {
+		if bet.PredictionID == id {
+			bets = append(bets, bet)
+		}
+	}
+
+	return types.PredictionWithOdds{
+		Prediction: prediction,
+		Odds:       prediction.Odds(bets),
+	}, nil
+}
+
+func (s *Store) ListArchivedPredictionsWithOdds() []types.PredictionWithOdds {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	betsByPrediction := map[string][]types.Bet{}
+	for k := range s.archive.Bets {
+		betsByPrediction[s.archive.Bets[k].PredictionID] = append(betsByPrediction[s.archive.Bets[k].PredictionID], s.archive.Bets[k])
+	}
+
+	predictions := make([]types.PredictionWithOdds, 0, len(s.archive.Predictions))
+	for _, p := range s.archive.Predictions {
+		predictions = append(predictions, types.PredictionWithOdds{
+			Prediction: p,
+			Odds:       p.Odds(betsByPrediction[p.ID]),
+		})
+	}
+	return predictions
+}
+
+func (s *Store) ListArchivedBetsByUser(userID string) []types.Bet {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	bets := make([]types.Bet, 0)
+	for _, bet := range s.archive.Bets {
+		if bet.UserID == userID {
+			bets = append(bets, bet)
+		}
+	}
+	return bets
+}
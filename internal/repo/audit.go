@@ -0,0 +1,27 @@
+package repo
+
+import (
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// Audit log methods
+
+func (s *Store) AddAuditEntry(e types.AuditEntry) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.dirty = true
+
+	s.auditLog[e.ID] = e
+}
+
+func (s *Store) ListAuditEntries() []types.AuditEntry {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	entries := make([]types.AuditEntry, 0, len(s.auditLog))
+	for _, e := range s.auditLog {
+		entries = append(entries, e)
+	}
+	return entries
+}
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"errors"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// Commit-reveal methods
+
+var ErrRevealRequired = errors.New("prediction has a commitment, deciding it needs the salt")
+var ErrPredictionCommitted = errors.New("prediction is committed to a host-known answer")
+var ErrRevealMismatch = errors.New("winning choice and salt don't match the prediction's commitment")
+
+// checkReveal makes sure deciding a committed prediction with choice and salt matches what the host committed to.
+// Predictions without a commitment always pass.
+func checkReveal(p types.Prediction, choice, salt string) error {
+	if p.Commitment == "" {
+		return nil
+	}
+	if salt == "" {
+		return ErrRevealRequired
+	}
+	if !p.RevealMatches(choice, salt) {
+		return ErrRevealMismatch
+	}
+	return nil
+}
+
+// publishRevealLocked records the salt of a decided committed prediction so players can check the commitment.
+func (s *Store) publishRevealLocked(id, salt string) {
+	p, ok := s.predictions[id]
+	if !ok || p.Commitment == "" {
+		return
+	}
+	p.CommitmentSalt = salt
+	s.predictions[id] = p
+}
@@ -0,0 +1,182 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// Two-admin decision methods
+
+var ErrDecisionAlreadyPending = errors.New("a different decision is already waiting for confirmation")
+var ErrNoPendingDecision = errors.New("no decision is waiting for confirmation")
+var ErrDecisionNeedsDifferentAdmin = errors.New("a decision must be confirmed by a different admin than the one who requested it")
+
+// RequestDecision records a decision, void or redecide for a second admin to confirm.
+// The prediction must be in a state where the decision could be applied right now.
+func (s *Store) RequestDecision(id string, d types.PendingDecision) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.predictions[id]
+	if !ok {
+		return ErrPredictionNotFound
+	}
+
+	if p.PendingDecision != nil {
+		return ErrDecisionAlreadyPending
+	}
+
+	if d.Action == types.DecisionActionDecide {
+		if p.Status != types.PredictionStatusClosed {
+			return ErrPredictionNotInClosedState
+		}
+		if p.HouseGame != nil {
+			return ErrPredictionIsHouseGame
+		}
+		validChoice := false
+		for _, c := range p.Choices {
+			if c.ID == d.WinningChoiceID {
+				validChoice = true
+				break
+			}
+		}
+		if !validChoice {
+			return ErrPredictionChoiceNotFound
+		}
+		if err := checkReveal(p, d.WinningChoiceID, d.Salt); err != nil {
+			return err
+		}
+	}
+
+	if d.Action == types.DecisionActionRedecide {
+		dispute, ok := s.disputes[d.DisputeID]
+		if !ok || dispute.PredictionID != id {
+			return ErrDisputeNotFound
+		}
+		if dispute.Status != types.DisputeStatusOpen {
+			return ErrDisputeNotOpen
+		}
+		if err := checkRedecide(p, d.WinningChoiceID); err != nil {
+			return err
+		}
+		if err := s.checkConditionalChildrenLocked(id); err != nil {
+			return err
+		}
+	}
+
+	s.dirty = true
+
+	p.PendingDecision = &d
+	s.predictions[id] = p
+
+	return nil
+}
+
+// decideOrRequestLocked decides a closed prediction with choice, unless confirmAbove is set and more tokens than that
+// are placed on it. Then the decision is left pending in requestedBy's name for an admin to confirm instead.
+// Returns true if the decision was left pending.
+func (s *Store) decideOrRequestLocked(id, choice, requestedBy string, confirmAbove int64) (bool, error) {
+	p, ok := s.predictions[id]
+	if !ok {
+		return false, ErrPredictionNotFound
+	}
+
+	if confirmAbove <= 0 || p.Odds(s.listBetsByPredictionLocked(id)).TotalTokensPlaced <= confirmAbove {
+		return false, s.decidePredictionLocked(id, choice)
+	}
+
+	if p.Status != types.PredictionStatusClosed {
+		return false, ErrPredictionNotInClosedState
+	}
+	if p.PendingDecision != nil {
+		return false, ErrDecisionAlreadyPending
+	}
+
+	s.dirty = true
+
+	p.PendingDecision = &types.PendingDecision{
+		Action:            types.DecisionActionDecide,
+		WinningChoiceID:   choice,
+		RequestedByUserID: requestedBy,
+		RequestedAt:       time.Now().Format(time.RFC3339),
+	}
+	s.predictions[id] = p
+
+	return true, nil
+}
+
+// ConfirmDecision applies a pending decision once a different admin confirms it, recording both approvals.
+func (s *Store) ConfirmDecision(id, adminID string) (types.PendingDecision, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.predictions[id]
+	if !ok {
+		return types.PendingDecision{}, ErrPredictionNotFound
+	}
+
+	if p.PendingDecision == nil {
+		return types.PendingDecision{}, ErrNoPendingDecision
+	}
+	pending := *p.PendingDecision
+
+	if pending.RequestedByUserID == adminID {
+		return types.PendingDecision{}, ErrDecisionNeedsDifferentAdmin
+	}
+
+	var err error
+	switch pending.Action {
+	case types.DecisionActionVoid:
+		err = s.voidPredictionLocked(id)
+	case types.DecisionActionRedecide:
+		_, err = s.redecideDisputeLocked(pending.DisputeID, adminID, pending.WinningChoiceID, pending.Note)
+	default:
+		err = s.decidePredictionLocked(id, pending.WinningChoiceID)
+	}
+	if err != nil {
+		return types.PendingDecision{}, err
+	}
+
+	if pending.Action == types.DecisionActionDecide {
+		s.publishRevealLocked(id, pending.Salt)
+	}
+
+	p = s.predictions[id]
+	p.DecisionApprovals = append(p.DecisionApprovals, types.DecisionApproval{
+		UserID:          pending.RequestedByUserID,
+		Action:          pending.Action,
+		WinningChoiceID: pending.WinningChoiceID,
+		ApprovedAt:      pending.RequestedAt,
+	}, types.DecisionApproval{
+		UserID:          adminID,
+		Action:          pending.Action,
+		WinningChoiceID: pending.WinningChoiceID,
+		ApprovedAt:      time.Now().Format(time.RFC3339),
+	})
+	s.predictions[id] = p
+
+	return pending, nil
+}
+
+func (s *Store) CancelPendingDecision(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.predictions[id]
+	if !ok {
+		return ErrPredictionNotFound
+	}
+
+	if p.PendingDecision == nil {
+		return ErrNoPendingDecision
+	}
+
+	s.dirty = true
+
+	p.PendingDecision = nil
+	s.predictions[id] = p
+
+	return nil
+}
@@ -0,0 +1,400 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// Dispute methods
+
+var ErrDisputeNotFound = errors.New("dispute not found")
+var ErrDisputeNotOpen = errors.New("dispute has already been resolved")
+var ErrDisputeWindowClosed = errors.New("prediction is not in its dispute window")
+var ErrNotEligibleToDispute = errors.New("only bettors can dispute a decision")
+var ErrDisputeAlreadyOpen = errors.New("user already has an open dispute on this prediction")
+var ErrPredictionNotDecided = errors.New("prediction not in decided state")
+var ErrSameDecision = errors.New("prediction is already decided that way")
+var ErrConditionalPredictionDecided = errors.New("a conditional prediction on this one has already been decided")
+
+// inDisputeWindow returns true if the decided prediction was decided less than window ago.
+func inDisputeWindow(p types.Prediction, window time.Duration, now time.Time) bool {
+	if p.Status != types.PredictionStatusDecided || window <= 0 || p.FinishedAt == "" {
+		return false
+	}
+	finishedAt, err := time.Parse(time.RFC3339, p.FinishedAt)
+	if err != nil {
+		return false
+	}
+	return now.Before(finishedAt.Add(window))
+}
+
+// AddDispute files a bettor's dispute against a prediction decided less than window ago.
+func (s *Store) AddDispute(d types.Dispute, window time.Duration) (types.Dispute, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.predictions[d.PredictionID]
+	if !ok {
+		return types.Dispute{}, ErrPredictionNotFound
+	}
+	if !inDisputeWindow(p, window, time.Now()) {
+		return types.Dispute{}, ErrDisputeWindowClosed
+	}
+	if p.HouseGame != nil {
+		return types.Dispute{}, ErrPredictionIsHouseGame
+	}
+	if !s.hasStakeOnPredictionLocked(d.UserID, d.PredictionID) {
+		return types.Dispute{}, ErrNotEligibleToDispute
+	}
+	for _, existing := range s.disputes {
+		if existing.PredictionID == d.PredictionID && existing.UserID == d.UserID && existing.Status == types.DisputeStatusOpen {
+			return types.Dispute{}, ErrDisputeAlreadyOpen
+		}
+	}
+
+	s.dirty = true
+
+	d.Status = types.DisputeStatusOpen
+	d.DisputedChoiceID = p.WinningChoiceID
+	s.disputes[d.ID] = d
+
+	return d, nil
+}
+
+func (s *Store) GetDispute(id string) (types.Dispute, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	dispute, ok := s.disputes[id]
+	if !ok {
+		return types.Dispute{}, ErrDisputeNotFound
+	}
+	return dispute, nil
+}
+
+func (s *Store) ListDisputes() []types.Dispute {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	disputes := make([]types.Dispute, 0, len(s.disputes))
+	for _, d := range s.disputes {
+		disputes = append(disputes, d)
+	}
+	return disputes
+}
+
+func (s *Store) HasOpenDisputes(predictionID string) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, d := range s.disputes {
+		if d.PredictionID == predictionID && d.Status == types.DisputeStatusOpen {
+			return true
+		}
+	}
+	return false
+}
+
+// UpholdDispute keeps the prediction's decision and closes the dispute.
+func (s *Store) UpholdDispute(id, adminID, note string) (types.Dispute, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	dispute, ok := s.disputes[id]
+	if !ok {
+		return types.Dispute{}, ErrDisputeNotFound
+	}
+	if dispute.Status != types.DisputeStatusOpen {
+		return types.Dispute{}, ErrDisputeNotOpen
+	}
+
+	s.dirty = true
+
+	// a redecide waiting for confirmation was about this dispute, it's settled now
+	if p, ok := s.predictions[dispute.PredictionID]; ok && p.PendingDecision != nil && p.PendingDecision.DisputeID == id {
+		p.PendingDecision = nil
+		s.predictions[p.ID] = p
+	}
+
+	dispute.Status = types.DisputeStatusUpheld
+	dispute.ResolvedAt = time.Now().Format(time.RFC3339)
+	dispute.ResolvedByUserID = adminID
+	dispute.ResolutionNote = note
+	s.disputes[id] = dispute
+
+	return dispute, nil
+}
+
+// RedecideDispute takes back the payouts of the disputed prediction and decides it again with choice.
+// Every open dispute on the prediction is resolved as redecided.
+func (s *Store) RedecideDispute(id, adminID, choice, note string) (types.Dispute, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.redecideDisputeLocked(id, adminID, choice, note)
+}
+
+func (s *Store) redecideDisputeLocked(id, adminID, choice, note string) (types.Dispute, error) {
+	dispute, ok := s.disputes[id]
+	if !ok {
+		return types.Dispute{}, ErrDisputeNotFound
+	}
+	if dispute.Status != types.DisputeStatusOpen {
+		return types.Dispute{}, ErrDisputeNotOpen
+	}
+
+	if err := s.redecidePredictionLocked(dispute.PredictionID, choice); err != nil {
+		return types.Dispute{}, err
+	}
+
+	resolvedAt := time.Now().Format(time.RFC3339)
+	for disputeID, d := range s.disputes {
+		if d.PredictionID != dispute.PredictionID || d.Status != types.DisputeStatusOpen {
+			continue
+		}
+		d.Status = types.DisputeStatusRedecided
+		d.ResolvedAt = resolvedAt
+		d.ResolvedByUserID = adminID
+		d.ResolutionNote = note
+		s.disputes[disputeID] = d
+	}
+
+	return s.disputes[id], nil
+}
+
+// checkRedecide returns why a decided prediction can't be decided again with choice, if it can't.
+func checkRedecide(p types.Prediction, choice string) error {
+	if p.Status != types.PredictionStatusDecided {
+		return ErrPredictionNotDecided
+	}
+	if p.WinningChoiceID == choice {
+		return ErrSameDecision
+	}
+	validChoice := false
+	for _, c := range p.Choices {
+		if c.ID == choice {
+			validChoice = true
+			break
+		}
+	}
+	if !validChoice {
+		return ErrPredictionChoiceNotFound
+	}
+	// the host's commitment is the answer, a dispute can't move it elsewhere
+	return checkReveal(p, choice, p.CommitmentSalt)
+}
+
+// redecidePredictionLocked reverts a decided prediction back to closed, taking back bet and parlay payouts,
+// then decides it again.
+func (s *Store) redecidePredictionLocked(id, choice string) error {
+	p, ok := s.predictions[id]
+	if !ok {
+		return ErrPredictionNotFound
+	}
+	if err := checkRedecide(p, choice); err != nil {
+		return err
+	}
+	if err := s.checkConditionalChildrenLocked(id); err != nil {
+		return err
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	takeBacks := []types.TokenLog{}
+	owed := map[string]int64{}
+
+	bets := s.listBetsByPredictionLocked(id)
+	for _, bet := range bets {
+		if bet.Status != types.BetStatusWon || bet.WonAmount <= 0 {
+			continue
+		}
+		logID, err := NewID()
+		if err != nil {
+			return err
+		}
+		takeBacks = append(takeBacks, types.TokenLog{
+			ID:           logID,
+			CreatedAt:    now,
+			UserID:       bet.UserID,
+			Change:       -bet.WonAmount,
+			Cause:        types.TokenChangeCauseRedecided,
+			BetID:        bet.ID,
+			PredictionID: id,
+		})
+		owed[bet.UserID] += bet.WonAmount
+	}
+
+	for _, parlay := range s.parlays {
+		if parlay.Status != types.ParlayStatusWon || parlay.WonAmount <= 0 || !parlayHasLeg(parlay, id) {
+			continue
+		}
+		logID, err := NewID()
+		if err != nil {
+			return err
+		}
+		takeBacks = append(takeBacks, types.TokenLog{
+			ID:        logID,
+			CreatedAt: now,
+			UserID:    parlay.UserID,
+			Change:    -parlay.WonAmount,
+			Cause:     types.TokenChangeCauseRedecided,
+			ParlayID:  parlay.ID,
+		})
+		owed[parlay.UserID] += parlay.WonAmount
+	}
+
+	// check up front so a winner who already spent their payout doesn't leave things half-reverted
+	for userID, amount := range owed {
+		if s.users[userID].Tokens < amount {
+			return ErrTokensWouldBeNegative
+		}
+	}
+
+	s.dirty = true
+
+	for i := range takeBacks {
+		if err := s.applyTokenLogLocked(takeBacks[i]); err != nil {
+			return err
+		}
+	}
+
+	for _, bet := range bets {
+		if bet.Status != types.BetStatusWon && bet.Status != types.BetStatusLost {
+			continue
+		}
+		bet.Status = types.BetStatusPlaced
+		bet.WonAmount = 0
+		s.bets[bet.ID] = bet
+	}
+
+	for parlayID, parlay := range s.parlays {
+		if parlay.Status == types.ParlayStatusVoided || !parlayHasLeg(parlay, id) {
+			continue
+		}
+		legs := make([]types.ParlayLeg, len(parlay.Legs))
+		copy(legs, parlay.Legs)
+		for i := range legs {
+			if legs[i].PredictionID == id && (legs[i].Status == types.ParlayLegStatusWon || legs[i].Status == types.ParlayLegStatusLost) {
+				legs[i].Status = types.ParlayLegStatusPending
+				legs[i].OddsBasisPoints = 0
+			}
+		}
+		parlay.Legs = legs
+		parlay.Status = types.ParlayStatusPlaced
+		parlay.WonAmount = 0
+		s.parlays[parlayID] = parlay
+	}
+
+	p.Status = types.PredictionStatusClosed
+	p.WinningChoiceID = ""
+	s.predictions[id] = p
+
+	if err := s.decidePredictionLocked(id, choice); err != nil {
+		return err
+	}
+
+	return s.redecideConditionalChildrenLocked(id, choice)
+}
+
+// checkConditionalChildrenLocked returns ErrConditionalPredictionDecided if a conditional prediction on id
+// has been decided, since its payouts can't be taken back along with id's.
+func (s *Store) checkConditionalChildrenLocked(id string) error {
+	for _, child := range s.predictions {
+		if child.ParentPredictionID == id && child.Status == types.PredictionStatusDecided {
+			return ErrConditionalPredictionDecided
+		}
+	}
+	return nil
+}
+
+// redecideConditionalChildrenLocked moves the conditional predictions on id over to its new decision.
+// Ones opened under the old decision are voided, refunding their bets. Ones voided because they didn't match it
+// go back to pending if they match choice, and the sweep opens them like it would have.
+func (s *Store) redecideConditionalChildrenLocked(id, choice string) error {
+	for childID, child := range s.predictions {
+		if child.ParentPredictionID != id {
+			continue
+		}
+		switch {
+		case child.Status == types.PredictionStatusOpen || child.Status == types.PredictionStatusClosed:
+			if err := s.voidPredictionLocked(childID); err != nil {
+				return err
+			}
+		case child.Status == types.PredictionStatusVoid && child.ParentChoiceID == choice:
+			s.restoreVoidedConditionalLocked(childID)
+		}
+	}
+	return nil
+}
+
+// restoreVoidedConditionalLocked puts a conditional prediction voided while it was still pending back to pending,
+// along with the conditional predictions on it that were voided with it.
+// Predictions with bets were voided after opening, and stay voided.
+func (s *Store) restoreVoidedConditionalLocked(id string) {
+	p := s.predictions[id]
+	if p.Status != types.PredictionStatusVoid || len(s.listBetsByPredictionLocked(id)) > 0 {
+		return
+	}
+
+	s.dirty = true
+
+	p.Status = types.PredictionStatusPending
+	p.FinishedAt = ""
+	s.predictions[id] = p
+
+	for childID, child := range s.predictions {
+		if child.ParentPredictionID == id {
+			s.restoreVoidedConditionalLocked(childID)
+		}
+	}
+}
+
+func parlayHasLeg(parlay types.Parlay, predictionID string) bool {
+	for _, leg := range parlay.Legs {
+		if leg.PredictionID == predictionID && leg.Status != types.ParlayLegStatusVoided {
+			return true
+		}
+	}
+	return false
+}
+
+// HoldDecisionRewards marks a decided prediction's achievements and coins as waiting for its dispute window.
+func (s *Store) HoldDecisionRewards(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.predictions[id]
+	if !ok {
+		return ErrPredictionNotFound
+	}
+
+	s.dirty = true
+
+	p.RewardsHeld = true
+	s.predictions[id] = p
+
+	return nil
+}
+
+// ReleaseDecisionRewards clears RewardsHeld, returning true if this call released them
+// (so rewards are only handed out once).
+func (s *Store) ReleaseDecisionRewards(id string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.predictions[id]
+	if !ok {
+		return false, ErrPredictionNotFound
+	}
+	if !p.RewardsHeld {
+		return false, nil
+	}
+
+	s.dirty = true
+
+	p.RewardsHeld = false
+	s.predictions[id] = p
+
+	return true, nil
+}
@@ -0,0 +1,119 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// Market freeze methods
+
+var ErrMarketFrozen = errors.New("market is frozen")
+var ErrMarketNotFrozen = errors.New("market is not frozen")
+
+func (s *Store) GetFreeze() types.MarketFreeze {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.freeze
+}
+
+func (s *Store) IsFrozen() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.freeze.Frozen
+}
+
+// Freeze stops all betting until Thaw.
+func (s *Store) Freeze(adminID, reason string) (types.MarketFreeze, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.freeze.Frozen {
+		return types.MarketFreeze{}, ErrMarketFrozen
+	}
+
+	s.dirty = true
+
+	s.freeze = types.MarketFreeze{
+		Frozen:         true,
+		FrozenAt:       time.Now().Format(time.RFC3339),
+		FrozenByUserID: adminID,
+		Reason:         reason,
+	}
+
+	return s.freeze, nil
+}
+
+// noteDeadlinesSetLocked records that a prediction's deadlines started running (or were set) now.
+// While the market is frozen, Thaw then only pushes them back by the part of the freeze after this.
+func (s *Store) noteDeadlinesSetLocked(id string) {
+	if !s.freeze.Frozen {
+		return
+	}
+	if s.freeze.DeadlinesSetAt == nil {
+		s.freeze.DeadlinesSetAt = map[string]string{}
+	}
+	s.freeze.DeadlinesSetAt[id] = time.Now().Format(time.RFC3339)
+}
+
+// Thaw lifts the freeze and pushes back the deadlines of open and waiting predictions by however long they were frozen
+// (the whole freeze, or since they were created, opened, reopened or rescheduled during it), so deadlines pick up
+// where they left off. It returns the IDs of the predictions whose ClosesAt moved.
+func (s *Store) Thaw() ([]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.freeze.Frozen {
+		return nil, ErrMarketNotFrozen
+	}
+
+	now := time.Now()
+	frozenAt, err := time.Parse(time.RFC3339, s.freeze.FrozenAt)
+	if err != nil {
+		frozenAt = now
+	}
+
+	s.dirty = true
+
+	moved := []string{}
+	for id, p := range s.predictions {
+		if p.Status != types.PredictionStatusOpen && p.Status != types.PredictionStatusScheduled && p.Status != types.PredictionStatusPending {
+			continue
+		}
+		if p.Status != types.PredictionStatusOpen && p.OpensAt == "" {
+			continue // waiting on its parent, not on a clock
+		}
+
+		pausedSince := frozenAt
+		if setAt, ok := s.freeze.DeadlinesSetAt[id]; ok {
+			if t, err := time.Parse(time.RFC3339, setAt); err == nil && t.After(pausedSince) {
+				pausedSince = t
+			}
+		}
+		pausedFor := now.Sub(pausedSince).Truncate(time.Second)
+		if pausedFor <= 0 {
+			continue
+		}
+
+		// predictions that haven't opened yet keep the same betting window once they do
+		if p.Status != types.PredictionStatusOpen {
+			if opensAt, err := time.Parse(time.RFC3339, p.OpensAt); err == nil {
+				p.OpensAt = opensAt.Add(pausedFor).Format(time.RFC3339)
+			}
+		}
+		if p.ClosesAt != "" {
+			if closesAt, err := time.Parse(time.RFC3339, p.ClosesAt); err == nil {
+				p.ClosesAt = closesAt.Add(pausedFor).Format(time.RFC3339)
+				moved = append(moved, id)
+			}
+		}
+		s.predictions[id] = p
+	}
+
+	s.freeze = types.MarketFreeze{}
+
+	return moved, nil
+}
@@ -0,0 +1,96 @@
+package repo
+
+import (
+	"errors"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// House game methods
+
+var ErrPredictionIsHouseGame = errors.New("house games are decided by the server")
+var ErrNotHouseGame = errors.New("prediction is not a house game")
+var ErrHouseSeedMissing = errors.New("house game round has no server seed")
+
+// AddHouseGameRound adds a new house game round along with its server seed, which stays secret until DecideHouseGameRound.
+func (s *Store) AddHouseGameRound(p types.Prediction, serverSeed string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if p.HouseGame == nil {
+		return ErrNotHouseGame
+	}
+	if _, ok := s.predictions[p.ID]; ok {
+		return ErrPredictionAlreadyExists
+	}
+
+	s.dirty = true
+
+	s.predictions[p.ID] = p
+	s.houseSeeds[p.ID] = serverSeed
+	s.noteDeadlinesSetLocked(p.ID)
+
+	return nil
+}
+
+// DecideHouseGameRound rolls a closed house game round, decides it with the winning choice, and reveals its server seed.
+func (s *Store) DecideHouseGameRound(id string) (types.HouseGameRound, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.predictions[id]
+	if !ok {
+		return types.HouseGameRound{}, ErrPredictionNotFound
+	}
+	if p.HouseGame == nil {
+		return types.HouseGameRound{}, ErrNotHouseGame
+	}
+	if p.Status != types.PredictionStatusClosed {
+		return types.HouseGameRound{}, ErrPredictionNotInClosedState
+	}
+	serverSeed, ok := s.houseSeeds[id]
+	if !ok {
+		return types.HouseGameRound{}, ErrHouseSeedMissing
+	}
+
+	roll, winner := p.HouseGame.Kind.Outcome(serverSeed, id)
+	choice := ""
+	for _, c := range p.Choices {
+		if c.Name == winner {
+			choice = c.ID
+			break
+		}
+	}
+
+	if err := s.decidePredictionLocked(id, choice); err != nil {
+		return types.HouseGameRound{}, err
+	}
+
+	p = s.predictions[id]
+	round := *p.HouseGame
+	round.ServerSeed = serverSeed
+	round.Roll = &roll
+	p.HouseGame = &round
+	s.predictions[id] = p
+	delete(s.houseSeeds, id)
+
+	return round, nil
+}
+
+// LatestHouseGameRounds returns the most recently created round of each house game kind.
+func (s *Store) LatestHouseGameRounds() map[types.HouseGameKind]types.Prediction {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	latest := map[types.HouseGameKind]types.Prediction{}
+	for _, p := range s.predictions {
+		if p.HouseGame == nil {
+			continue
+		}
+		if existing, ok := latest[p.HouseGame.Kind]; ok && existing.ID > p.ID {
+			continue
+		}
+		latest[p.HouseGame.Kind] = p
+	}
+	return latest
+}
@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// Oracle methods
+
+var ErrOracleNotFound = errors.New("oracle not found")
+var ErrPredictionAlreadyFinished = errors.New("prediction has already been decided or voided")
+
+// PutOracle attaches an oracle to a prediction, replacing any oracle it already had.
+func (s *Store) PutOracle(o types.Oracle) (types.Oracle, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.predictions[o.PredictionID]
+	if !ok {
+		return types.Oracle{}, ErrPredictionNotFound
+	}
+	if p.Status == types.PredictionStatusDecided || p.Status == types.PredictionStatusVoid {
+		return types.Oracle{}, ErrPredictionAlreadyFinished
+	}
+	if p.Commitment != "" {
+		return types.Oracle{}, ErrPredictionCommitted
+	}
+	if p.HouseGame != nil {
+		return types.Oracle{}, ErrPredictionIsHouseGame
+	}
+	for _, choiceID := range o.ChoiceValues {
+		validChoice := false
+		for _, c := range p.Choices {
+			if c.ID == choiceID {
+				validChoice = true
+				break
+			}
+		}
+		if !validChoice {
+			return types.Oracle{}, ErrPredictionChoiceNotFound
+		}
+	}
+
+	s.dirty = true
+
+	s.oracles[o.PredictionID] = o
+
+	return o, nil
+}
+
+func (s *Store) GetOracle(predictionID string) (types.Oracle, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	oracle, ok := s.oracles[predictionID]
+	if !ok {
+		return types.Oracle{}, ErrOracleNotFound
+	}
+	return oracle, nil
+}
+
+func (s *Store) ListOracles() []types.Oracle {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	oracles := make([]types.Oracle, 0, len(s.oracles))
+	for _, o := range s.oracles {
+		oracles = append(oracles, o)
+	}
+	return oracles
+}
+
+func (s *Store) DeleteOracle(predictionID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.oracles[predictionID]; !ok {
+		return ErrOracleNotFound
+	}
+
+	s.dirty = true
+
+	delete(s.oracles, predictionID)
+
+	return nil
+}
+
+// RecordOracleCheck remembers the outcome of the latest poll or webhook so admins can see what the oracle is doing.
+func (s *Store) RecordOracleCheck(predictionID, result, problem string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	oracle, ok := s.oracles[predictionID]
+	if !ok {
+		return
+	}
+
+	s.dirty = true
+
+	oracle.LastCheckedAt = time.Now().Format(time.RFC3339)
+	oracle.LastResult = result
+	oracle.LastError = problem
+	s.oracles[predictionID] = oracle
+}
+
+// ResolveByOracle closes the prediction if it's still open, then decides it with choice. If more than confirmAbove
+// tokens are placed on it, the decision is left pending for an admin to confirm instead.
+// It returns whether the prediction was closed by this call, and whether the decision was left pending.
+func (s *Store) ResolveByOracle(predictionID, choice string, confirmAbove int64) (bool, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.predictions[predictionID]
+	if !ok {
+		return false, false, ErrPredictionNotFound
+	}
+	if p.Status != types.PredictionStatusOpen && p.Status != types.PredictionStatusClosed {
+		return false, false, ErrPredictionNotInClosedState
+	}
+
+	validChoice := false
+	for _, c := range p.Choices {
+		if c.ID == choice {
+			validChoice = true
+			break
+		}
+	}
+	if !validChoice {
+		return false, false, ErrPredictionChoiceNotFound
+	}
+	if p.Commitment != "" {
+		return false, false, ErrPredictionCommitted
+	}
+	if p.HouseGame != nil {
+		return false, false, ErrPredictionIsHouseGame
+	}
+
+	s.dirty = true
+
+	closed := false
+	if p.Status == types.PredictionStatusOpen {
+		p.Status = types.PredictionStatusClosed
+		p.ClosedAt = time.Now().Format(time.RFC3339)
+		s.predictions[predictionID] = p
+		closed = true
+	}
+
+	pending, err := s.decideOrRequestLocked(predictionID, choice, types.DecisionRequestedByOracle, confirmAbove)
+	return closed, pending, err
+}
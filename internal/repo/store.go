@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"reflect"
 	"sort"
 	"strings"
 	"sync"
@@ -27,9 +28,24 @@ type Store struct {
 	users            map[string]types.User
 	predictions      map[string]types.Prediction
 	bets             map[string]types.Bet
+	parlays          map[string]types.Parlay
+	occasions        map[string]types.Occasion
+	templates        map[string]types.PredictionTemplate
+	proposals        map[string]types.Proposal
+	votes            map[string]types.ResolutionVote
+	disputes         map[string]types.Dispute
+	oracles          map[string]types.Oracle // prediction ID -> oracle
+	houseSeeds       map[string]string       // prediction ID -> server seed of an undecided house game round
 	tokenLog         map[string]types.TokenLog
+	auditLog         map[string]types.AuditEntry
 	sessions         map[string]string                  // session token -> user ID
 	userAchievements map[string][]types.UserAchievement // user ID -> achievements
+	archivedLosses   map[string]int64                   // user ID -> tokens lost on archived bets
+	freeze           types.MarketFreeze
+
+	// archive holds finished predictions moved out of the maps above. See archive.go
+	archive      archiveCopy
+	archiveDirty bool
 }
 
 func NewStore() *Store {
@@ -37,9 +53,20 @@ func NewStore() *Store {
 		users:            make(map[string]types.User),
 		predictions:      make(map[string]types.Prediction),
 		bets:             make(map[string]types.Bet),
+		parlays:          make(map[string]types.Parlay),
+		occasions:        make(map[string]types.Occasion),
+		templates:        make(map[string]types.PredictionTemplate),
+		proposals:        make(map[string]types.Proposal),
+		votes:            make(map[string]types.ResolutionVote),
+		disputes:         make(map[string]types.Dispute),
+		oracles:          make(map[string]types.Oracle),
+		houseSeeds:       make(map[string]string),
 		tokenLog:         make(map[string]types.TokenLog),
+		auditLog:         make(map[string]types.AuditEntry),
 		sessions:         make(map[string]string),
 		userAchievements: make(map[string][]types.UserAchievement),
+		archivedLosses:   make(map[string]int64),
+		archive:          newArchiveCopy(),
 	}
 }
 
@@ -47,9 +74,20 @@ type storeCopy struct {
 	Users            map[string]types.User
 	Predictions      map[string]types.Prediction
 	Bets             map[string]types.Bet
+	Parlays          map[string]types.Parlay
+	Occasions        map[string]types.Occasion
+	Templates        map[string]types.PredictionTemplate
+	Proposals        map[string]types.Proposal
+	Votes            map[string]types.ResolutionVote
+	Disputes         map[string]types.Dispute
+	Oracles          map[string]types.Oracle
+	HouseSeeds       map[string]string
 	TokenLog         map[string]types.TokenLog
+	AuditLog         map[string]types.AuditEntry
 	Sessions         map[string]string
 	UserAchievements map[string][]types.UserAchievement
+	ArchivedLosses   map[string]int64
+	Freeze           types.MarketFreeze
 }
 
 func (s *Store) Save(w io.Writer) error {
@@ -62,9 +100,20 @@ func (s *Store) Save(w io.Writer) error {
 		Users:            s.users,
 		Predictions:      s.predictions,
 		Bets:             s.bets,
+		Parlays:          s.parlays,
+		Occasions:        s.occasions,
+		Templates:        s.templates,
+		Proposals:        s.proposals,
+		Votes:            s.votes,
+		Disputes:         s.disputes,
+		Oracles:          s.oracles,
+		HouseSeeds:       s.houseSeeds,
 		TokenLog:         s.tokenLog,
+		AuditLog:         s.auditLog,
 		Sessions:         s.sessions,
 		UserAchievements: s.userAchievements,
+		ArchivedLosses:   s.archivedLosses,
+		Freeze:           s.freeze,
 	})
 }
 
@@ -85,23 +134,64 @@ func (s *Store) Load(r io.Reader) error {
 	if copy.Bets == nil {
 		copy.Bets = make(map[string]types.Bet)
 	}
+	if copy.Parlays == nil {
+		copy.Parlays = make(map[string]types.Parlay)
+	}
+	if copy.Occasions == nil {
+		copy.Occasions = make(map[string]types.Occasion)
+	}
+	if copy.Templates == nil {
+		copy.Templates = make(map[string]types.PredictionTemplate)
+	}
+	if copy.Proposals == nil {
+		copy.Proposals = make(map[string]types.Proposal)
+	}
+	if copy.Votes == nil {
+		copy.Votes = make(map[string]types.ResolutionVote)
+	}
+	if copy.Disputes == nil {
+		copy.Disputes = make(map[string]types.Dispute)
+	}
+	if copy.Oracles == nil {
+		copy.Oracles = make(map[string]types.Oracle)
+	}
+	if copy.HouseSeeds == nil {
+		copy.HouseSeeds = make(map[string]string)
+	}
 	if copy.TokenLog == nil {
 		copy.TokenLog = make(map[string]types.TokenLog)
 	}
+	if copy.AuditLog == nil {
+		copy.AuditLog = make(map[string]types.AuditEntry)
+	}
 	if copy.Sessions == nil {
 		copy.Sessions = make(map[string]string)
 	}
 	if copy.UserAchievements == nil {
 		copy.UserAchievements = make(map[string][]types.UserAchievement)
 	}
+	if copy.ArchivedLosses == nil {
+		copy.ArchivedLosses = make(map[string]int64)
+	}
 
 	s.dirty = false
 	s.users = copy.Users
 	s.predictions = copy.Predictions
 	s.bets = copy.Bets
+	s.parlays = copy.Parlays
+	s.occasions = copy.Occasions
+	s.templates = copy.Templates
+	s.proposals = copy.Proposals
+	s.votes = copy.Votes
+	s.disputes = copy.Disputes
+	s.oracles = copy.Oracles
+	s.houseSeeds = copy.HouseSeeds
 	s.tokenLog = copy.TokenLog
+	s.auditLog = copy.AuditLog
 	s.sessions = copy.Sessions
 	s.userAchievements = copy.UserAchievements
+	s.archivedLosses = copy.ArchivedLosses
+	s.freeze = copy.Freeze
 
 	return nil
 }
@@ -160,19 +250,46 @@ func (s *Store) PutPrediction(p types.Prediction) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
-	if existing, ok := s.predictions[p.ID]; ok {
-		if existing.Status != types.PredictionStatusOpen {
+	existing, ok := s.predictions[p.ID]
+	if ok {
+		if existing.Status != types.PredictionStatusOpen && existing.Status != types.PredictionStatusPending && existing.Status != types.PredictionStatusScheduled {
 			return ErrPredictionNotOpen
 		}
 	}
 
 	s.dirty = true
 
+	if !ok || existing.OpensAt != p.OpensAt || existing.ClosesAt != p.ClosesAt || existing.Status != p.Status {
+		s.noteDeadlinesSetLocked(p.ID)
+	}
 	s.predictions[p.ID] = p
 
 	return nil
 }
 
+var ErrPredictionAlreadyExists = errors.New("prediction already exists")
+
+// AddPredictions adds several new predictions at once. Either all of them are added or none are.
+func (s *Store) AddPredictions(ps []types.Prediction) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, p := range ps {
+		if _, ok := s.predictions[p.ID]; ok {
+			return ErrPredictionAlreadyExists
+		}
+	}
+
+	s.dirty = true
+
+	for _, p := range ps {
+		s.predictions[p.ID] = p
+		s.noteDeadlinesSetLocked(p.ID)
+	}
+
+	return nil
+}
+
 var ErrTokensWouldBeNegative = errors.New("token log change would make tokens negative, refusing")
 
 func (s *Store) applyTokenLogLocked(tc types.TokenLog) error {
@@ -284,6 +401,47 @@ func (s *Store) UpdateUserPIN(id string, hash []byte) error {
 	return nil
 }
 
+func (s *Store) SetUserAdmin(id string, admin bool) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	s.dirty = true
+
+	user.Admin = admin
+	s.users[user.ID] = user
+
+	return nil
+}
+
+// SetUserRoles replaces the user's roles, and the occasions they host if they're a host.
+func (s *Store) SetUserRoles(id string, roles []types.Role, hostOccasionIDs []string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return ErrUserNotFound
+	}
+	for _, occasionID := range hostOccasionIDs {
+		if _, ok := s.occasions[occasionID]; !ok {
+			return ErrOccasionNotFound
+		}
+	}
+
+	s.dirty = true
+
+	user.Roles = roles
+	user.HostOccasionIDs = hostOccasionIDs
+	s.users[user.ID] = user
+
+	return nil
+}
+
 func (s *Store) IncrementSpins(id string) (int64, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -301,6 +459,28 @@ func (s *Store) IncrementSpins(id string) (int64, error) {
 	return user.Spins, nil
 }
 
+// ClaimMinigameCoins awards coins earned in the minigame. Refused while the market is frozen.
+func (s *Store) ClaimMinigameCoins(userID string, amount int64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.freeze.Frozen {
+		return ErrMarketFrozen
+	}
+
+	user, ok := s.users[userID]
+	if !ok {
+		return ErrUserNotFound
+	}
+
+	if amount > 0 {
+		s.dirty = true
+		user.Coins += amount
+		s.users[userID] = user
+	}
+	return nil
+}
+
 func (s *Store) IncrementMinigamePlays(id string) (int64, error) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -390,10 +570,15 @@ func (s *Store) AddCoins(userID string, amount int64) error {
 	return nil
 }
 
+// DeductCoins spends a user's coins in the shop. Refused while the market is frozen.
 func (s *Store) DeductCoins(userID string, amount int64) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	if s.freeze.Frozen {
+		return ErrMarketFrozen
+	}
+
 	user, ok := s.users[userID]
 	if !ok {
 		return ErrUserNotFound
@@ -566,10 +751,34 @@ func (s *Store) ListPredictionsWithOdds() []types.PredictionWithOdds {
 
 var ErrPredictionNotInClosedState = errors.New("prediction not in closed state")
 
-func (s *Store) DecidePrediction(id, choice string) error {
+// DecidePrediction decides the prediction with choice. Committed predictions also need the salt,
+// which is published once the reveal checks out.
+func (s *Store) DecidePrediction(id, choice, salt string) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	p, ok := s.predictions[id]
+	if !ok {
+		return ErrPredictionNotFound
+	}
+	if p.Status != types.PredictionStatusClosed {
+		return ErrPredictionNotInClosedState
+	}
+	if p.HouseGame != nil {
+		return ErrPredictionIsHouseGame
+	}
+	if err := checkReveal(p, choice, salt); err != nil {
+		return err
+	}
+
+	if err := s.decidePredictionLocked(id, choice); err != nil {
+		return err
+	}
+	s.publishRevealLocked(id, salt)
+	return nil
+}
+
+func (s *Store) decidePredictionLocked(id, choice string) error {
 	p, ok := s.predictions[id]
 	if !ok {
 		return ErrPredictionNotFound
@@ -652,16 +861,22 @@ func (s *Store) DecidePrediction(id, choice string) error {
 
 	// update prediction
 	p.Status = types.PredictionStatusDecided
+	p.FinishedAt = time.Now().Format(time.RFC3339)
 	p.WinningChoiceID = choice
+	p.PendingDecision = nil
 	s.predictions[p.ID] = p
 
-	return nil
+	return s.decideParlayLegsLocked(id, choice, winningOdds)
 }
 
 func (s *Store) VoidPrediction(id string) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	return s.voidPredictionLocked(id)
+}
+
+func (s *Store) voidPredictionLocked(id string) error {
 	p, ok := s.predictions[id]
 	if !ok {
 		return ErrPredictionNotFound
@@ -720,7 +935,116 @@ func (s *Store) VoidPrediction(id string) error {
 		s.bets[bets[i].ID] = bet
 	}
 
-	return nil
+	p.Status = types.PredictionStatusVoid
+	p.FinishedAt = time.Now().Format(time.RFC3339)
+	p.PendingDecision = nil
+	s.predictions[p.ID] = p
+	delete(s.houseSeeds, id) // voided house game rounds are never rolled
+
+	return s.voidParlayLegsLocked(id, "")
+}
+
+var ErrPredictionNeedsChoices = errors.New("prediction must have at least 2 choices")
+var ErrPredictionDuplicateChoice = errors.New("prediction choices must have unique IDs")
+
+var ErrPredictionChanged = errors.New("prediction changed since it was read")
+
+// UpdatePrediction saves changes to a prediction that hasn't closed yet, including its choices.
+// read is the prediction the changes were made to: if it has changed since (ex: a sweep or an anti-snipe extension),
+// nothing is saved and ErrPredictionChanged is returned, so the changes can be made again to the new version.
+// Choices with a new ID are added, and existing choices missing from the list are removed:
+// bets (and parlay legs) on a removed choice are refunded through the token log.
+// Nothing is refunded unless the whole update can be saved.
+// Returns the IDs of users who were refunded.
+func (s *Store) UpdatePrediction(read, p types.Prediction) ([]string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	existing, ok := s.predictions[p.ID]
+	if !ok {
+		return nil, ErrPredictionNotFound
+	}
+	if !reflect.DeepEqual(existing, read) {
+		return nil, ErrPredictionChanged
+	}
+
+	if existing.Status != types.PredictionStatusOpen && existing.Status != types.PredictionStatusPending && existing.Status != types.PredictionStatusScheduled {
+		return nil, ErrPredictionNotOpen
+	}
+
+	if len(p.Choices) < 2 {
+		return nil, ErrPredictionNeedsChoices
+	}
+
+	kept := make(map[string]struct{}, len(p.Choices))
+	for _, c := range p.Choices {
+		if _, dup := kept[c.ID]; dup {
+			return nil, ErrPredictionDuplicateChoice
+		}
+		kept[c.ID] = struct{}{}
+	}
+
+	removed := []string{}
+	for _, c := range existing.Choices {
+		if _, ok := kept[c.ID]; !ok {
+			removed = append(removed, c.ID)
+		}
+	}
+
+	bets := s.listBetsByPredictionLocked(p.ID)
+	refunds := []types.TokenLog{}
+	refunded := []string{}
+	voided := []types.Bet{}
+	for i := range bets {
+		if bets[i].Status != types.BetStatusPlaced {
+			continue
+		}
+		if _, ok := kept[bets[i].PredictionChoiceID]; ok {
+			continue
+		}
+
+		logID, err := NewID()
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, types.TokenLog{
+			ID:           logID,
+			CreatedAt:    time.Now().Format(time.RFC3339),
+			UserID:       bets[i].UserID,
+			Change:       bets[i].Amount,
+			Cause:        types.TokenChangeCauseBetVoided,
+			BetID:        bets[i].ID,
+			PredictionID: p.ID,
+		})
+		refunded = append(refunded, bets[i].UserID)
+		bets[i].Status = types.BetStatusVoided
+		voided = append(voided, bets[i])
+	}
+
+	s.dirty = true
+
+	for i := range refunds {
+		if err := s.applyTokenLogLocked(refunds[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range voided {
+		s.bets[voided[i].ID] = voided[i]
+	}
+
+	for _, choiceID := range removed {
+		if err := s.voidParlayLegsLocked(p.ID, choiceID); err != nil {
+			return nil, err
+		}
+	}
+
+	if existing.OpensAt != p.OpensAt || existing.ClosesAt != p.ClosesAt || existing.Status != p.Status {
+		s.noteDeadlinesSetLocked(p.ID)
+	}
+	s.predictions[p.ID] = p
+
+	return refunded, nil
 }
 
 func (s *Store) ClosePrediction(id string) error {
@@ -739,11 +1063,81 @@ func (s *Store) ClosePrediction(id string) error {
 	s.dirty = true
 
 	p.Status = types.PredictionStatusClosed
+	p.ClosedAt = time.Now().Format(time.RFC3339)
+	s.predictions[id] = p
+
+	return nil
+}
+
+var ErrPredictionNotWaitingToOpen = errors.New("prediction not in pending or scheduled state")
+
+// OpenPrediction opens a conditional prediction once its parent has been decided the right way,
+// or a scheduled prediction once its OpensAt time has passed.
+func (s *Store) OpenPrediction(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.predictions[id]
+	if !ok {
+		return ErrPredictionNotFound
+	}
+
+	if p.Status != types.PredictionStatusPending && p.Status != types.PredictionStatusScheduled {
+		return ErrPredictionNotWaitingToOpen
+	}
+
+	s.dirty = true
+
+	p.Status = types.PredictionStatusOpen
 	s.predictions[id] = p
+	s.noteDeadlinesSetLocked(id)
 
 	return nil
 }
 
+// ExtendClosesAtForLateBet applies the prediction's AntiSnipe rule for a bet placed at betAt.
+// Returns the (possibly new) ClosesAt, and whether it moved.
+func (s *Store) ExtendClosesAtForLateBet(id string, betAt time.Time) (string, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.predictions[id]
+	if !ok {
+		return "", false, ErrPredictionNotFound
+	}
+
+	if p.Status != types.PredictionStatusOpen || p.AntiSnipe == nil || p.ClosesAt == "" {
+		return p.ClosesAt, false, nil
+	}
+
+	closesAt, err := time.Parse(time.RFC3339, p.ClosesAt)
+	if err != nil {
+		return p.ClosesAt, false, err
+	}
+
+	remaining := closesAt.Sub(betAt)
+	if remaining < 0 || remaining > time.Duration(p.AntiSnipe.WindowSeconds)*time.Second {
+		return p.ClosesAt, false, nil
+	}
+
+	extension := p.AntiSnipe.ExtendSeconds
+	if left := p.AntiSnipe.MaxExtensionSeconds - p.ClosesAtExtendedSeconds; extension > left {
+		extension = left
+	}
+	if extension <= 0 {
+		// cap reached
+		return p.ClosesAt, false, nil
+	}
+
+	s.dirty = true
+
+	p.ClosesAt = closesAt.Add(time.Duration(extension) * time.Second).Format(time.RFC3339)
+	p.ClosesAtExtendedSeconds += extension
+	s.predictions[id] = p
+
+	return p.ClosesAt, true, nil
+}
+
 func (s *Store) ReopenPrediction(id string) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -760,17 +1154,282 @@ func (s *Store) ReopenPrediction(id string) error {
 	s.dirty = true
 
 	p.Status = types.PredictionStatusOpen
+	p.ClosedAt = ""
+	// any resolution vote or pending decision starts over once it closes again
+	p.VoteEscalated = false
+	p.PendingDecision = nil
 	s.predictions[id] = p
+	s.supersedeResolutionVotesLocked(id)
+	s.noteDeadlinesSetLocked(id)
+
+	return nil
+}
+
+// Occasion methods
+
+var ErrOccasionNotFound = errors.New("occasion not found")
+
+func (s *Store) PutOccasion(o types.Occasion) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.dirty = true
+
+	s.occasions[o.ID] = o
+
+	return nil
+}
+
+func (s *Store) GetOccasion(id string) (types.Occasion, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	occasion, ok := s.occasions[id]
+	if !ok {
+		return types.Occasion{}, ErrOccasionNotFound
+	}
+	return occasion, nil
+}
+
+func (s *Store) ListOccasions() []types.Occasion {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	occasions := make([]types.Occasion, 0, len(s.occasions))
+	for _, o := range s.occasions {
+		occasions = append(occasions, o)
+	}
+	return occasions
+}
+
+// Template methods
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+func (s *Store) PutTemplate(t types.PredictionTemplate) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.dirty = true
+
+	s.templates[t.ID] = t
+
+	return nil
+}
+
+func (s *Store) GetTemplate(id string) (types.PredictionTemplate, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	template, ok := s.templates[id]
+	if !ok {
+		return types.PredictionTemplate{}, ErrTemplateNotFound
+	}
+	return template, nil
+}
+
+func (s *Store) ListTemplates() []types.PredictionTemplate {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	templates := make([]types.PredictionTemplate, 0, len(s.templates))
+	for _, t := range s.templates {
+		templates = append(templates, t)
+	}
+	return templates
+}
+
+func (s *Store) DeleteTemplate(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.templates[id]; !ok {
+		return ErrTemplateNotFound
+	}
+
+	s.dirty = true
+
+	delete(s.templates, id)
+
+	return nil
+}
+
+// Proposal methods
+
+var ErrProposalNotFound = errors.New("proposal not found")
+var ErrProposalNotPending = errors.New("proposal has already been resolved")
+var ErrTooManyPendingProposals = errors.New("user has too many pending proposals")
+
+// AddProposal stores a new proposal, refusing if the proposer already has maxPending proposals waiting.
+func (s *Store) AddProposal(p types.Proposal, maxPending int) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.users[p.UserID]; !ok {
+		return ErrUserNotFound
+	}
+
+	pending := 0
+	for _, existing := range s.proposals {
+		if existing.UserID == p.UserID && existing.Status == types.ProposalStatusPending {
+			pending++
+		}
+	}
+	if maxPending > 0 && pending >= maxPending {
+		return ErrTooManyPendingProposals
+	}
+
+	s.dirty = true
+
+	s.proposals[p.ID] = p
 
 	return nil
 }
 
+func (s *Store) GetProposal(id string) (types.Proposal, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	proposal, ok := s.proposals[id]
+	if !ok {
+		return types.Proposal{}, ErrProposalNotFound
+	}
+	return proposal, nil
+}
+
+func (s *Store) ListProposals() []types.Proposal {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	proposals := make([]types.Proposal, 0, len(s.proposals))
+	for _, p := range s.proposals {
+		proposals = append(proposals, p)
+	}
+	return proposals
+}
+
+func (s *Store) ListProposalsByUser(userID string) []types.Proposal {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	proposals := []types.Proposal{}
+	for _, p := range s.proposals {
+		if p.UserID == userID {
+			proposals = append(proposals, p)
+		}
+	}
+	return proposals
+}
+
+func (s *Store) getPendingProposalLocked(id string) (types.Proposal, error) {
+	proposal, ok := s.proposals[id]
+	if !ok {
+		return types.Proposal{}, ErrProposalNotFound
+	}
+	if proposal.Status != types.ProposalStatusPending {
+		return types.Proposal{}, ErrProposalNotPending
+	}
+	return proposal, nil
+}
+
+// ApproveProposal adds the prediction made from a pending proposal and rewards the proposer with coins.
+func (s *Store) ApproveProposal(id string, prediction types.Prediction, rewardCoins int64) (types.Proposal, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	proposal, err := s.getPendingProposalLocked(id)
+	if err != nil {
+		return types.Proposal{}, err
+	}
+	if _, ok := s.predictions[prediction.ID]; ok {
+		return types.Proposal{}, ErrPredictionAlreadyExists
+	}
+
+	s.dirty = true
+
+	s.predictions[prediction.ID] = prediction
+	s.noteDeadlinesSetLocked(prediction.ID)
+
+	if user, ok := s.users[proposal.UserID]; ok && rewardCoins > 0 {
+		user.Coins += rewardCoins
+		s.users[user.ID] = user
+		proposal.RewardCoins = rewardCoins
+	}
+
+	proposal.Status = types.ProposalStatusApproved
+	proposal.PredictionID = prediction.ID
+	proposal.ResolvedAt = time.Now().Format(time.RFC3339)
+	s.proposals[id] = proposal
+
+	return proposal, nil
+}
+
+func (s *Store) RejectProposal(id, reason string) (types.Proposal, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	proposal, err := s.getPendingProposalLocked(id)
+	if err != nil {
+		return types.Proposal{}, err
+	}
+
+	s.dirty = true
+
+	proposal.Status = types.ProposalStatusRejected
+	proposal.RejectionReason = reason
+	proposal.ResolvedAt = time.Now().Format(time.RFC3339)
+	s.proposals[id] = proposal
+
+	return proposal, nil
+}
+
+// MergeProposal marks a pending proposal as a duplicate of an existing prediction.
+func (s *Store) MergeProposal(id, predictionID string) (types.Proposal, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	proposal, err := s.getPendingProposalLocked(id)
+	if err != nil {
+		return types.Proposal{}, err
+	}
+	if _, ok := s.predictions[predictionID]; !ok {
+		return types.Proposal{}, ErrPredictionNotFound
+	}
+
+	s.dirty = true
+
+	proposal.Status = types.ProposalStatusMerged
+	proposal.PredictionID = predictionID
+	proposal.ResolvedAt = time.Now().Format(time.RFC3339)
+	s.proposals[id] = proposal
+
+	return proposal, nil
+}
+
 // Bet methods
 
 var ErrBetNotFound = errors.New("bet not found")
 var ErrBetAmountMustBePositive = errors.New("bet amount must be positive")
 var ErrBetAlreadyExistsForPrediction = errors.New("a bet already exists by this user for this prediction")
 var ErrPredictionChoiceNotFound = errors.New("prediction found but choice does not exist")
+var ErrBetBelowMinimum = errors.New("bet is below the prediction's minimum")
+var ErrBetAboveMaximum = errors.New("bet is above the prediction's maximum")
+var ErrBetAboveBankrollPercent = errors.New("bet is above the prediction's max percent of bankroll")
+
+// checkBetLimitsLocked validates a bet's total amount against the prediction's limits.
+// bankroll is every token the user could have on this bet: their balance plus whatever is already staked on it.
+func (s *Store) checkBetLimitsLocked(p types.Prediction, bankroll, amount int64) error {
+	if p.MinBet > 0 && amount < p.MinBet {
+		return ErrBetBelowMinimum
+	}
+	if p.MaxBet > 0 && amount > p.MaxBet {
+		return ErrBetAboveMaximum
+	}
+	if p.MaxBankrollPercent > 0 && amount*100 > bankroll*p.MaxBankrollPercent {
+		return ErrBetAboveBankrollPercent
+	}
+	return nil
+}
 
 func (s *Store) CreateBet(bet types.Bet) error {
 	if bet.Amount <= 0 {
@@ -780,6 +1439,10 @@ func (s *Store) CreateBet(bet types.Bet) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	if s.freeze.Frozen {
+		return ErrMarketFrozen
+	}
+
 	if _, exists := s.getUserBetOnPredictionLocked(bet.UserID, bet.PredictionID); exists {
 		return ErrBetAlreadyExistsForPrediction
 	}
@@ -803,6 +1466,10 @@ func (s *Store) CreateBet(bet types.Bet) error {
 		return ErrPredictionChoiceNotFound
 	}
 
+	if err := s.checkBetLimitsLocked(prediction, s.users[bet.UserID].Tokens, bet.Amount); err != nil {
+		return err
+	}
+
 	logID, err := NewID()
 	if err != nil {
 		return err
@@ -846,6 +1513,10 @@ func (s *Store) IncreaseBet(betID string, to int64) error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 
+	if s.freeze.Frozen {
+		return ErrMarketFrozen
+	}
+
 	bet, ok := s.bets[betID]
 	if !ok {
 		return ErrBetNotFound
@@ -876,6 +1547,10 @@ func (s *Store) IncreaseBet(betID string, to int64) error {
 		return ErrBetAlreadyHigher // sanity check
 	}
 
+	if err := s.checkBetLimitsLocked(prediction, s.users[bet.UserID].Tokens+bet.Amount, to); err != nil {
+		return err
+	}
+
 	logID, err := NewID()
 	if err != nil {
 		return err
@@ -918,6 +1593,17 @@ func (s *Store) ListBetsByPrediction(predictionID string) []types.Bet {
 	return s.listBetsByPredictionLocked(predictionID)
 }
 
+func (s *Store) ListBets() []types.Bet {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	bets := make([]types.Bet, 0, len(s.bets))
+	for _, bet := range s.bets {
+		bets = append(bets, bet)
+	}
+	return bets
+}
+
 func (s *Store) ListBetsByUser(userID string) []types.Bet {
 	s.lock.RLock()
 	defer s.lock.RUnlock()
@@ -933,6 +1619,9 @@ func (s *Store) ListBetsByUser(userID string) []types.Bet {
 
 func (s *Store) getUserBetOnPredictionLocked(userID, predictionID string) (types.Bet, bool) {
 	for _, bet := range s.bets {
+		if bet.Status == types.BetStatusVoided {
+			continue // refunded, e.g. because its choice was removed
+		}
 		if bet.UserID == userID && bet.PredictionID == predictionID {
 			return bet, true
 		}
@@ -940,6 +1629,265 @@ func (s *Store) getUserBetOnPredictionLocked(userID, predictionID string) (types
 	return types.Bet{}, false
 }
 
+// Parlay methods
+
+var ErrParlayNotFound = errors.New("parlay not found")
+var ErrParlayNeedsMultipleLegs = errors.New("parlay must have at least 2 legs")
+var ErrParlayDuplicatePrediction = errors.New("parlay has more than one leg on the same prediction")
+
+// CreateParlay places a parlay. Each leg is held to its prediction's bet limits as if the whole stake were bet on it.
+// If a leg is rejected, the ID of its prediction is returned along with the error.
+func (s *Store) CreateParlay(parlay types.Parlay) (string, error) {
+	if parlay.Amount <= 0 {
+		return "", ErrBetAmountMustBePositive
+	}
+	if len(parlay.Legs) < 2 {
+		return "", ErrParlayNeedsMultipleLegs
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.freeze.Frozen {
+		return "", ErrMarketFrozen
+	}
+
+	seen := map[string]struct{}{}
+	for _, leg := range parlay.Legs {
+		if _, dup := seen[leg.PredictionID]; dup {
+			return leg.PredictionID, ErrParlayDuplicatePrediction
+		}
+		seen[leg.PredictionID] = struct{}{}
+
+		prediction, err := s.getPredictionLocked(leg.PredictionID)
+		if err != nil {
+			return leg.PredictionID, err
+		}
+		if prediction.Status != types.PredictionStatusOpen {
+			return leg.PredictionID, ErrPredictionNotOpen
+		}
+
+		validChoice := false
+		for _, c := range prediction.Choices {
+			if c.ID == leg.PredictionChoiceID {
+				validChoice = true
+				break
+			}
+		}
+		if !validChoice {
+			return leg.PredictionID, ErrPredictionChoiceNotFound
+		}
+
+		if err := s.checkBetLimitsLocked(prediction, s.users[parlay.UserID].Tokens, parlay.Amount); err != nil {
+			return leg.PredictionID, err
+		}
+	}
+
+	logID, err := NewID()
+	if err != nil {
+		return "", err
+	}
+
+	s.dirty = true
+
+	err = s.applyTokenLogLocked(types.TokenLog{
+		ID:        logID,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		UserID:    parlay.UserID,
+		Change:    -parlay.Amount,
+		Cause:     types.TokenChangeCauseParlayPlaced,
+		ParlayID:  parlay.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.parlays[parlay.ID] = parlay
+
+	return "", nil
+}
+
+func (s *Store) GetParlay(id string) (types.Parlay, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	parlay, ok := s.parlays[id]
+	if !ok {
+		return types.Parlay{}, ErrParlayNotFound
+	}
+	return parlay, nil
+}
+
+func (s *Store) ListParlaysByUser(userID string) []types.Parlay {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	parlays := make([]types.Parlay, 0)
+	for _, parlay := range s.parlays {
+		if parlay.UserID == userID {
+			parlays = append(parlays, parlay)
+		}
+	}
+	return parlays
+}
+
+// decideParlayLegsLocked resolves every pending parlay leg on the decided prediction,
+// settling any parlay that no longer has pending legs.
+func (s *Store) decideParlayLegsLocked(predictionID, winningChoiceID string, winningOdds int64) error {
+	for id, parlay := range s.parlays {
+		if parlay.Status != types.ParlayStatusPlaced {
+			continue
+		}
+
+		changed := false
+		legs := make([]types.ParlayLeg, len(parlay.Legs))
+		copy(legs, parlay.Legs)
+		for i := range legs {
+			if legs[i].PredictionID != predictionID || legs[i].Status != types.ParlayLegStatusPending {
+				continue
+			}
+			if legs[i].PredictionChoiceID == winningChoiceID {
+				legs[i].Status = types.ParlayLegStatusWon
+				legs[i].OddsBasisPoints = winningOdds
+			} else {
+				legs[i].Status = types.ParlayLegStatusLost
+			}
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		parlay.Legs = legs
+
+		if err := s.settleParlayLocked(&parlay); err != nil {
+			return err
+		}
+
+		s.dirty = true
+		s.parlays[id] = parlay
+	}
+
+	return nil
+}
+
+// voidParlayLegsLocked drops the voided prediction out of every parlay it is a leg of.
+// If choiceID is set, only legs on that choice are dropped.
+// Parlays that were already settled are reopened (reverting any payout) and settled again without the leg.
+func (s *Store) voidParlayLegsLocked(predictionID, choiceID string) error {
+	for id, parlay := range s.parlays {
+		if parlay.Status == types.ParlayStatusVoided {
+			continue
+		}
+
+		changed := false
+		legs := make([]types.ParlayLeg, len(parlay.Legs))
+		copy(legs, parlay.Legs)
+		for i := range legs {
+			if legs[i].PredictionID != predictionID || legs[i].Status == types.ParlayLegStatusVoided {
+				continue
+			}
+			if choiceID != "" && legs[i].PredictionChoiceID != choiceID {
+				continue
+			}
+			legs[i].Status = types.ParlayLegStatusVoided
+			legs[i].OddsBasisPoints = 0
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+		parlay.Legs = legs
+
+		if parlay.Status == types.ParlayStatusWon && parlay.WonAmount > 0 {
+			logID, err := NewID()
+			if err != nil {
+				return err
+			}
+			err = s.applyTokenLogLocked(types.TokenLog{
+				ID:        logID,
+				CreatedAt: time.Now().Format(time.RFC3339),
+				UserID:    parlay.UserID,
+				Change:    -parlay.WonAmount,
+				Cause:     types.TokenChangeCauseParlayVoided,
+				ParlayID:  parlay.ID,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		parlay.Status = types.ParlayStatusPlaced
+		parlay.WonAmount = 0
+
+		if err := s.settleParlayLocked(&parlay); err != nil {
+			return err
+		}
+
+		s.dirty = true
+		s.parlays[id] = parlay
+	}
+
+	return nil
+}
+
+// settleParlayLocked pays out, loses or refunds a placed parlay once none of its legs are pending.
+func (s *Store) settleParlayLocked(parlay *types.Parlay) error {
+	won, lost := 0, 0
+	for _, leg := range parlay.Legs {
+		switch leg.Status {
+		case types.ParlayLegStatusPending:
+			return nil // not ready yet
+		case types.ParlayLegStatusWon:
+			won++
+		case types.ParlayLegStatusLost:
+			lost++
+		}
+	}
+
+	if lost > 0 {
+		parlay.Status = types.ParlayStatusLost
+		return nil
+	}
+
+	logID, err := NewID()
+	if err != nil {
+		return err
+	}
+
+	if won == 0 {
+		// every leg was voided, refund the stake
+		err = s.applyTokenLogLocked(types.TokenLog{
+			ID:        logID,
+			CreatedAt: time.Now().Format(time.RFC3339),
+			UserID:    parlay.UserID,
+			Change:    parlay.Amount,
+			Cause:     types.TokenChangeCauseParlayVoided,
+			ParlayID:  parlay.ID,
+		})
+		if err != nil {
+			return err
+		}
+		parlay.Status = types.ParlayStatusVoided
+		return nil
+	}
+
+	payout := parlay.Payout()
+	err = s.applyTokenLogLocked(types.TokenLog{
+		ID:        logID,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		UserID:    parlay.UserID,
+		Change:    payout,
+		Cause:     types.TokenChangeCauseParlayWon,
+		ParlayID:  parlay.ID,
+	})
+	if err != nil {
+		return err
+	}
+	parlay.Status = types.ParlayStatusWon
+	parlay.WonAmount = payout
+
+	return nil
+}
+
 // Achievement methods
 
 func (s *Store) GetUserAchievements(userID string) []types.UserAchievement {
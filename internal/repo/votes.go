@@ -0,0 +1,207 @@
+package repo
+
+import (
+	"errors"
+	"time"
+
+	"go.albinodrought.com/creamy-prediction-market/internal/types"
+)
+
+// Resolution vote methods
+
+var ErrPredictionNotVoteResolved = errors.New("prediction is not resolved by vote")
+var ErrResolutionVoteEscalated = errors.New("resolution vote has been escalated to an admin")
+var ErrNotEligibleToVote = errors.New("user is not eligible to vote on this prediction")
+var ErrAlreadyVoted = errors.New("user has already voted on this prediction")
+
+// hasStakeOnPredictionLocked returns true if the user has a bet or a parlay leg riding on the prediction.
+func (s *Store) hasStakeOnPredictionLocked(userID, predictionID string) bool {
+	if _, ok := s.getUserBetOnPredictionLocked(userID, predictionID); ok {
+		return true
+	}
+	for _, parlay := range s.parlays {
+		if parlay.UserID != userID || parlay.Status == types.ParlayStatusVoided {
+			continue
+		}
+		for _, leg := range parlay.Legs {
+			if leg.PredictionID == predictionID && leg.Status != types.ParlayLegStatusVoided {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Store) eligibleVoterLocked(p types.Prediction, userID string) bool {
+	if p.VoteResolution.Voters == types.VoterPoolNonBettors {
+		return !s.hasStakeOnPredictionLocked(userID, p.ID)
+	}
+	return true
+}
+
+// activePlayersLocked returns the IDs of users who have bet, parlayed or voted on anything.
+func (s *Store) activePlayersLocked() map[string]struct{} {
+	active := map[string]struct{}{}
+	for _, bet := range s.bets {
+		active[bet.UserID] = struct{}{}
+	}
+	for _, parlay := range s.parlays {
+		active[parlay.UserID] = struct{}{}
+	}
+	for _, vote := range s.votes {
+		active[vote.UserID] = struct{}{}
+	}
+	return active
+}
+
+func (s *Store) resolutionVoteTallyLocked(predictionID string) (map[string]int64, int) {
+	tally := map[string]int64{}
+	votes := 0
+	for _, vote := range s.votes {
+		if vote.PredictionID == predictionID && !vote.Superseded {
+			tally[vote.ChoiceID]++
+			votes++
+		}
+	}
+	return tally, votes
+}
+
+// supersedeResolutionVotesLocked keeps the prediction's votes for auditing, but stops counting them.
+func (s *Store) supersedeResolutionVotesLocked(predictionID string) {
+	for id, vote := range s.votes {
+		if vote.PredictionID == predictionID && !vote.Superseded {
+			vote.Superseded = true
+			s.votes[id] = vote
+		}
+	}
+}
+
+// CastResolutionVote records a user's vote on the outcome of a closed, vote-resolved prediction.
+// It returns the choice that now has a quorum and supermajority, if any, for the caller to decide the prediction with.
+// If every eligible user has voted without a decision, the prediction is escalated to an admin.
+func (s *Store) CastResolutionVote(predictionID, userID, choiceID string) (types.ResolutionVote, string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.predictions[predictionID]
+	if !ok {
+		return types.ResolutionVote{}, "", ErrPredictionNotFound
+	}
+	if p.VoteResolution == nil {
+		return types.ResolutionVote{}, "", ErrPredictionNotVoteResolved
+	}
+	if p.Status != types.PredictionStatusClosed {
+		return types.ResolutionVote{}, "", ErrPredictionNotInClosedState
+	}
+	if p.VoteEscalated {
+		return types.ResolutionVote{}, "", ErrResolutionVoteEscalated
+	}
+
+	validChoice := false
+	for _, c := range p.Choices {
+		if c.ID == choiceID {
+			validChoice = true
+			break
+		}
+	}
+	if !validChoice {
+		return types.ResolutionVote{}, "", ErrPredictionChoiceNotFound
+	}
+
+	if _, ok := s.users[userID]; !ok {
+		return types.ResolutionVote{}, "", ErrUserNotFound
+	}
+	if !s.eligibleVoterLocked(p, userID) {
+		return types.ResolutionVote{}, "", ErrNotEligibleToVote
+	}
+	for _, vote := range s.votes {
+		if vote.PredictionID == predictionID && vote.UserID == userID && !vote.Superseded {
+			return types.ResolutionVote{}, "", ErrAlreadyVoted
+		}
+	}
+
+	voteID, err := NewID()
+	if err != nil {
+		return types.ResolutionVote{}, "", err
+	}
+
+	s.dirty = true
+
+	vote := types.ResolutionVote{
+		ID:           voteID,
+		CreatedAt:    time.Now().Format(time.RFC3339),
+		PredictionID: predictionID,
+		UserID:       userID,
+		ChoiceID:     choiceID,
+	}
+	s.votes[voteID] = vote
+
+	tally, votes := s.resolutionVoteTallyLocked(predictionID)
+	winner := p.VoteResolution.Winner(tally)
+	if winner != "" {
+		return vote, winner, nil
+	}
+
+	eligible := 0
+	for userID := range s.activePlayersLocked() {
+		if _, ok := s.users[userID]; ok && s.eligibleVoterLocked(p, userID) {
+			eligible++
+		}
+	}
+	if votes >= eligible {
+		p.VoteEscalated = true
+		s.predictions[predictionID] = p
+	}
+
+	return vote, "", nil
+}
+
+// EscalateResolutionVote hands an undecided resolution vote over to an admin.
+func (s *Store) EscalateResolutionVote(predictionID string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p, ok := s.predictions[predictionID]
+	if !ok {
+		return ErrPredictionNotFound
+	}
+	if p.VoteResolution == nil {
+		return ErrPredictionNotVoteResolved
+	}
+	if p.Status != types.PredictionStatusClosed {
+		return ErrPredictionNotInClosedState
+	}
+	if p.VoteEscalated {
+		return ErrResolutionVoteEscalated
+	}
+
+	s.dirty = true
+
+	p.VoteEscalated = true
+	s.predictions[predictionID] = p
+
+	return nil
+}
+
+// DecideByVote decides the prediction with the winner of its resolution vote. If more than confirmAbove tokens
+// are placed on it, the decision is left pending for an admin to confirm instead, and true is returned.
+func (s *Store) DecideByVote(predictionID, winner string, confirmAbove int64) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.decideOrRequestLocked(predictionID, winner, types.DecisionRequestedByVote, confirmAbove)
+}
+
+// ListResolutionVotes returns every vote on the prediction, including superseded ones.
+func (s *Store) ListResolutionVotes(predictionID string) []types.ResolutionVote {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	votes := []types.ResolutionVote{}
+	for _, vote := range s.votes {
+		if vote.PredictionID == predictionID {
+			votes = append(votes, vote)
+		}
+	}
+	return votes
+}
@@ -0,0 +1,33 @@
+package types
+
+// AuditEntry records a privileged action taken by an admin.
+type AuditEntry struct {
+	ID          string `json:"id"`
+	CreatedAt   string `json:"created_at"`
+	ActorUserID string `json:"actor_user_id"`
+	ActorName   string `json:"actor_name"`
+	// Action is the route that was called (ex: "POST /api/admin/predictions/{id}/decide")
+	Action string `json:"action"`
+	Status int    `json:"status"`
+
+	// TargetType and TargetID are what the action was taken on (ex: "predictions" and a prediction ID).
+	// For actions that create something, TargetID is the new thing.
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   string `json:"target_id,omitempty"`
+	// ResultID is something else the action created, like the prediction an approved proposal or a clone turned into
+	ResultID string `json:"result_id,omitempty"`
+	// AffectedIDs are other things a bulk action changed
+	AffectedIDs []string `json:"affected_ids,omitempty"`
+	// AffectedChanges are the fields of each affected thing that changed, by ID
+	AffectedChanges map[string]map[string]AuditChange `json:"affected_changes,omitempty"`
+
+	// Changes are the fields of the target that changed, by their JSON names
+	Changes map[string]AuditChange `json:"changes,omitempty"`
+	// Request is the request body of actions without a target, which have no before and after to compare
+	Request any `json:"request,omitempty"`
+}
+
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
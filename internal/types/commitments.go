@@ -0,0 +1,33 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// CommitmentHash is what a host commits to before a trivia-style prediction opens:
+// hex(SHA-256(salt + ":" + winning choice name)). Anyone can recompute it once the salt is published.
+func CommitmentHash(choiceName, salt string) string {
+	sum := sha256.Sum256([]byte(salt + ":" + choiceName))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidCommitment returns true if commitment looks like a hex-encoded SHA-256 hash.
+func ValidCommitment(commitment string) bool {
+	if len(commitment) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(commitment)
+	return err == nil
+}
+
+// RevealMatches returns true if deciding the prediction with choiceID and salt matches its commitment.
+func (p Prediction) RevealMatches(choiceID, salt string) bool {
+	for _, c := range p.Choices {
+		if c.ID == choiceID {
+			return strings.EqualFold(CommitmentHash(c.Name, salt), p.Commitment)
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package types
+
+type DecisionAction string
+
+const (
+	DecisionActionDecide = DecisionAction("decide")
+	DecisionActionVoid   = DecisionAction("void")
+	// DecisionActionRedecide takes back a decided prediction's payouts and decides it again, resolving a dispute
+	DecisionActionRedecide = DecisionAction("redecide")
+)
+
+// Decisions that reach the two-admin threshold without an admin, from a final oracle result or a resolution vote
+// reaching quorum, are requested by one of these instead of a user. Any admin can confirm them.
+const (
+	DecisionRequestedByOracle = "oracle"
+	DecisionRequestedByVote   = "vote"
+)
+
+// PendingDecision is a decision, void or redecide requested by one admin. It only applies once a different admin confirms it.
+type PendingDecision struct {
+	Action          DecisionAction `json:"action"`
+	WinningChoiceID string         `json:"winning_choice_id,omitempty"`
+	// Salt reveals the commitment of a committed prediction
+	Salt string `json:"salt,omitempty"`
+	// DisputeID and Note are the dispute a redecide resolves, and the note to resolve it with
+	DisputeID         string `json:"dispute_id,omitempty"`
+	Note              string `json:"note,omitempty"`
+	RequestedByUserID string `json:"requested_by_user_id"`
+	RequestedAt       string `json:"requested_at"`
+}
+
+// Matches returns true if the other decision would have the same outcome.
+func (d PendingDecision) Matches(other PendingDecision) bool {
+	return d.Action == other.Action && d.WinningChoiceID == other.WinningChoiceID
+}
+
+type DecisionApproval struct {
+	UserID          string         `json:"user_id"`
+	Action          DecisionAction `json:"action"`
+	WinningChoiceID string         `json:"winning_choice_id,omitempty"`
+	ApprovedAt      string         `json:"approved_at"`
+}
@@ -0,0 +1,28 @@
+package types
+
+type DisputeStatus string
+
+const (
+	// DisputeStatusOpen means the dispute is waiting for an admin
+	DisputeStatusOpen = DisputeStatus("open")
+	// DisputeStatusUpheld means an admin looked at the dispute and kept the decision
+	DisputeStatusUpheld = DisputeStatus("upheld")
+	// DisputeStatusRedecided means the prediction was decided again after the dispute
+	DisputeStatusRedecided = DisputeStatus("redecided")
+)
+
+// Dispute is a bettor's objection to how a prediction was decided, filed during the dispute window
+type Dispute struct {
+	ID           string        `json:"id"`
+	CreatedAt    string        `json:"created_at"`
+	PredictionID string        `json:"prediction_id"`
+	UserID       string        `json:"user_id"`
+	Note         string        `json:"note"`
+	Status       DisputeStatus `json:"status"`
+	// DisputedChoiceID is the winning choice at the time the dispute was filed
+	DisputedChoiceID string `json:"disputed_choice_id"`
+
+	ResolvedAt       string `json:"resolved_at,omitempty"`
+	ResolvedByUserID string `json:"resolved_by_user_id,omitempty"`
+	ResolutionNote   string `json:"resolution_note,omitempty"`
+}
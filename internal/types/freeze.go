@@ -0,0 +1,13 @@
+package types
+
+// MarketFreeze is the global maintenance switch. While the market is frozen, betting, shop purchases and
+// minigame claims are rejected and OpensAt/ClosesAt deadlines are paused.
+type MarketFreeze struct {
+	Frozen         bool   `json:"frozen"`
+	FrozenAt       string `json:"frozen_at,omitempty"`
+	FrozenByUserID string `json:"frozen_by_user_id,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+	// DeadlinesSetAt maps predictions created, opened, reopened or rescheduled during the freeze to when that happened,
+	// so their deadlines are only paused from then on
+	DeadlinesSetAt map[string]string `json:"deadlines_set_at,omitempty"`
+}
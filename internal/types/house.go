@@ -0,0 +1,114 @@
+package types
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+)
+
+type HouseGameKind string
+
+const (
+	HouseGameCoinFlip = HouseGameKind("coin_flip")
+	HouseGameDice     = HouseGameKind("dice")
+	HouseGameRoulette = HouseGameKind("roulette")
+)
+
+var HouseGameKinds = []HouseGameKind{HouseGameCoinFlip, HouseGameDice, HouseGameRoulette}
+
+// rouletteRed are the red pockets of a European wheel. 0 is green, everything else is black.
+var rouletteRed = map[int64]struct{}{
+	1: {}, 3: {}, 5: {}, 7: {}, 9: {}, 12: {}, 14: {}, 16: {}, 18: {},
+	19: {}, 21: {}, 23: {}, 25: {}, 27: {}, 30: {}, 32: {}, 34: {}, 36: {},
+}
+
+// HouseGameRound makes a prediction a house game, created and decided by the server.
+//
+// The outcome is provably fair: ServerSeedHash = hex(SHA-256(server seed)) is published when the round opens,
+// and once it's decided the seed is revealed and Roll = first 8 bytes of HMAC-SHA256(server seed, prediction ID),
+// read as a big-endian uint64, modulo the number of outcomes (2 for coin flips, 6 for dice, 37 for roulette).
+type HouseGameRound struct {
+	Kind           HouseGameKind `json:"kind"`
+	ServerSeedHash string        `json:"server_seed_hash"`
+	// ServerSeed is only set once the round has been decided
+	ServerSeed string `json:"server_seed,omitempty"`
+	// Roll is the coin side (0 heads, 1 tails), die face (1-6) or roulette pocket (0-36)
+	Roll *int64 `json:"roll,omitempty"`
+}
+
+func (k HouseGameKind) Valid() bool {
+	for _, kind := range HouseGameKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Title is used as the name of the kind's predictions.
+func (k HouseGameKind) Title() string {
+	switch k {
+	case HouseGameCoinFlip:
+		return "Coin flip"
+	case HouseGameDice:
+		return "Dice roll"
+	case HouseGameRoulette:
+		return "Roulette"
+	}
+	return string(k)
+}
+
+// ChoiceNames are the names of the choices each round of the kind offers.
+func (k HouseGameKind) ChoiceNames() []string {
+	switch k {
+	case HouseGameCoinFlip:
+		return []string{"Heads", "Tails"}
+	case HouseGameDice:
+		return []string{"1", "2", "3", "4", "5", "6"}
+	case HouseGameRoulette:
+		return []string{"Red", "Black", "Green"}
+	}
+	return nil
+}
+
+func (k HouseGameKind) outcomes() uint64 {
+	switch k {
+	case HouseGameCoinFlip:
+		return 2
+	case HouseGameDice:
+		return 6
+	case HouseGameRoulette:
+		return 37
+	}
+	return 1
+}
+
+// Outcome rolls the round and returns the roll and the name of the winning choice.
+func (k HouseGameKind) Outcome(serverSeed, predictionID string) (int64, string) {
+	mac := hmac.New(sha256.New, []byte(serverSeed))
+	mac.Write([]byte(predictionID))
+	roll := int64(binary.BigEndian.Uint64(mac.Sum(nil)[:8]) % k.outcomes())
+
+	switch k {
+	case HouseGameCoinFlip:
+		return roll, k.ChoiceNames()[roll]
+	case HouseGameDice:
+		return roll + 1, strconv.FormatInt(roll+1, 10)
+	case HouseGameRoulette:
+		if roll == 0 {
+			return roll, "Green"
+		}
+		if _, ok := rouletteRed[roll]; ok {
+			return roll, "Red"
+		}
+		return roll, "Black"
+	}
+	return roll, ""
+}
+
+func HouseGameSeedHash(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,49 @@
+package types
+
+import "testing"
+
+// The expected rolls were worked out separately from the formula in HouseGameRound's doc comment,
+// so players checking a round by hand get the same answer as the server.
+func TestHouseGameOutcome(t *testing.T) {
+	const id = "01a15054-9552-7133-91d8-4e81be4ebe90"
+	const otherID = "01a15054-95b7-72b5-ba00-d8e59eeb142a"
+
+	tests := []struct {
+		kind       HouseGameKind
+		seed       string
+		id         string
+		wantRoll   int64
+		wantWinner string
+	}{
+		{HouseGameCoinFlip, "seed-0", id, 1, "Tails"},
+		{HouseGameCoinFlip, "seed-1", id, 0, "Heads"},
+		{HouseGameCoinFlip, "seed-0", otherID, 1, "Tails"},
+
+		{HouseGameDice, "seed-1", id, 1, "1"},
+		{HouseGameDice, "seed-0", id, 2, "2"},
+		{HouseGameDice, "seed-5", id, 3, "3"},
+		{HouseGameDice, "seed-3", id, 4, "4"},
+		{HouseGameDice, "seed-7", id, 5, "5"},
+		{HouseGameDice, "seed-4", id, 6, "6"},
+		{HouseGameDice, "seed-0", otherID, 2, "2"},
+
+		{HouseGameRoulette, "seed-0", id, 27, "Red"},
+		{HouseGameRoulette, "seed-1", id, 17, "Black"},
+		{HouseGameRoulette, "seed-20", id, 0, "Green"},
+		{HouseGameRoulette, "seed-0", otherID, 18, "Red"},
+	}
+
+	for _, tt := range tests {
+		roll, winner := tt.kind.Outcome(tt.seed, tt.id)
+		if roll != tt.wantRoll || winner != tt.wantWinner {
+			t.Errorf("%s.Outcome(%q, %q) = %d, %q, want %d, %q", tt.kind, tt.seed, tt.id, roll, winner, tt.wantRoll, tt.wantWinner)
+		}
+	}
+}
+
+func TestHouseGameSeedHash(t *testing.T) {
+	const want = "8efd6168a055d1e45863c237eb3feab2be94f10b7ccdc5bf8bb54305c8b19f7f"
+	if got := HouseGameSeedHash("seed-0"); got != want {
+		t.Errorf("HouseGameSeedHash(%q) = %q, want %q", "seed-0", got, want)
+	}
+}
@@ -0,0 +1,29 @@
+package types
+
+import "strings"
+
+// Occasion groups the predictions of a single party or event (ex: "Super Bowl 2026", "Sam & Alex's wedding")
+type Occasion struct {
+	ID          string `json:"id"`
+	CreatedAt   string `json:"created_at"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty and duplicate ones.
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := map[string]struct{}{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
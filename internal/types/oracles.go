@@ -0,0 +1,55 @@
+package types
+
+import "strings"
+
+type OracleKind string
+
+const (
+	// OracleKindWebhook waits for a signed result to be pushed to the prediction's webhook
+	OracleKindWebhook = OracleKind("webhook")
+	// OracleKindPoll fetches a JSON endpoint every IntervalSeconds
+	OracleKindPoll = OracleKind("poll")
+)
+
+// Oracle closes and decides a prediction on its own once an external source reports a final result.
+// Oracles are kept apart from their prediction so the webhook secret is never sent to players.
+type Oracle struct {
+	PredictionID string     `json:"prediction_id"`
+	Kind         OracleKind `json:"kind"`
+
+	// Secret signs webhook payloads: the X-Oracle-Signature header must be "sha256=" + hex(HMAC-SHA256(secret, body))
+	Secret string `json:"secret,omitempty"`
+
+	// URL is the JSON endpoint polled by OracleKindPoll oracles
+	URL string `json:"url,omitempty"`
+	// IntervalSeconds is how often URL is polled. Defaults to 30.
+	IntervalSeconds int64 `json:"interval_seconds,omitempty"`
+
+	// Path picks the result out of the JSON document, JSONPath-style (ex: "$.game.winner", "results[0].name")
+	Path string `json:"path"`
+	// FinalPath optionally picks a value that says whether the result is final (ex: "$.game.status").
+	// Without it, any non-empty result is treated as final.
+	FinalPath string `json:"final_path,omitempty"`
+	// FinalValue is what FinalPath must equal for the result to be final (ex: "final"). Defaults to "true".
+	FinalValue string `json:"final_value,omitempty"`
+	// ChoiceValues maps results to choice IDs (ex: "KC" -> the "Chiefs" choice).
+	// Results without an entry are matched against choice names and IDs, ignoring case.
+	ChoiceValues map[string]string `json:"choice_values,omitempty"`
+
+	LastCheckedAt string `json:"last_checked_at,omitempty"`
+	LastResult    string `json:"last_result,omitempty"`
+	LastError     string `json:"last_error,omitempty"`
+}
+
+// ChoiceFor maps a result reported by the oracle to one of the prediction's choices, or "" if none match.
+func (o Oracle) ChoiceFor(p Prediction, result string) string {
+	if choiceID, ok := o.ChoiceValues[result]; ok {
+		return choiceID
+	}
+	for _, c := range p.Choices {
+		if strings.EqualFold(c.Name, result) || strings.EqualFold(c.ID, result) {
+			return c.ID
+		}
+	}
+	return ""
+}
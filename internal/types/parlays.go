@@ -0,0 +1,58 @@
+package types
+
+type ParlayStatus string
+
+const (
+	// ParlayStatusPlaced means at least one leg is still waiting on its prediction
+	ParlayStatusPlaced = ParlayStatus("placed")
+	// ParlayStatusWon means every non-voided leg won and the player has been paid out
+	ParlayStatusWon = ParlayStatus("won")
+	// ParlayStatusLost means at least one leg lost
+	ParlayStatusLost = ParlayStatus("lost")
+	// ParlayStatusVoided means every leg was voided and the stake has been refunded
+	ParlayStatusVoided = ParlayStatus("voided")
+)
+
+type ParlayLegStatus string
+
+const (
+	ParlayLegStatusPending = ParlayLegStatus("pending")
+	ParlayLegStatusWon     = ParlayLegStatus("won")
+	ParlayLegStatusLost    = ParlayLegStatus("lost")
+	ParlayLegStatusVoided  = ParlayLegStatus("voided")
+)
+
+// Parlay is a single combined bet across several predictions. Every leg must win for the parlay to pay out.
+type Parlay struct {
+	ID        string       `json:"id"`
+	CreatedAt string       `json:"created_at"`
+	UserID    string       `json:"user_id"`
+	Amount    int64        `json:"amount"`
+	Legs      []ParlayLeg  `json:"legs"`
+	Status    ParlayStatus `json:"status"`
+
+	WonAmount int64 `json:"won_amount"`
+}
+
+type ParlayLeg struct {
+	PredictionID       string          `json:"prediction_id"`
+	PredictionChoiceID string          `json:"prediction_choice_id"`
+	Status             ParlayLegStatus `json:"status"`
+
+	// OddsBasisPoints is the winning choice's payout multiplier at the time the leg's prediction was decided.
+	// 0 until the leg has won.
+	OddsBasisPoints int64 `json:"odds_basis_points"`
+}
+
+// Payout calculates the payout of a parlay by multiplying the odds of every won leg.
+// Voided legs are skipped. Only meaningful once no legs are pending or lost.
+func (p Parlay) Payout() int64 {
+	payout := p.Amount
+	for _, leg := range p.Legs {
+		if leg.Status != ParlayLegStatusWon {
+			continue
+		}
+		payout = (payout * leg.OddsBasisPoints) / 100
+	}
+	return payout
+}
@@ -1,8 +1,14 @@
 package types
 
+import "strings"
+
 type PredictionStatus string
 
 const (
+	// PredictionStatusPending means the prediction is waiting on its parent prediction to be decided before it opens
+	PredictionStatusPending = PredictionStatus("pending")
+	// PredictionStatusScheduled means the prediction is visible, but betting doesn't open until OpensAt
+	PredictionStatusScheduled = PredictionStatus("scheduled")
 	// PredictionStatusOpen means players can still place bets
 	PredictionStatusOpen = PredictionStatus("open")
 	// PredictionStatusClosed means players can no longer place bets, but the outcome isn't decided yet.
@@ -19,11 +25,70 @@ type Prediction struct {
 	Name            string             `json:"name"`
 	Description     string             `json:"description"`
 	Status          PredictionStatus   `json:"status"`
+	OpensAt         string             `json:"opens_at,omitempty"`
 	ClosesAt        string             `json:"closes_at"`
 	Choices         []PredictionChoice `json:"choices"`
 	WinningChoiceID string             `json:"winning_choice_id"`
+	// ClosedAt is when betting closed
+	ClosedAt string `json:"closed_at,omitempty"`
+	// FinishedAt is when the prediction was decided or voided
+	FinishedAt string `json:"finished_at,omitempty"`
+
+	// OccasionID optionally groups the prediction with others from the same party
+	OccasionID string   `json:"occasion_id,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
 
 	OddsVisibleBeforeBet bool `json:"odds_visible_before_bet"`
+	// Sealed hides the pool from everyone, admins and bettors included, until the prediction closes.
+	Sealed bool `json:"sealed,omitempty"`
+
+	// MinBet and MaxBet optionally limit the size of each bet. 0 means no limit.
+	MinBet int64 `json:"min_bet,omitempty"`
+	MaxBet int64 `json:"max_bet,omitempty"`
+	// MaxBankrollPercent optionally limits each bet to a percentage of the bettor's tokens. 0 means no limit.
+	MaxBankrollPercent int64 `json:"max_bankroll_percent,omitempty"`
+
+	// ParentPredictionID and ParentChoiceID make this a conditional prediction.
+	// It stays pending until the parent is decided, then opens if ParentChoiceID won or is voided otherwise.
+	ParentPredictionID string `json:"parent_prediction_id,omitempty"`
+	ParentChoiceID     string `json:"parent_choice_id,omitempty"`
+
+	// AntiSnipe optionally pushes ClosesAt back when bets come in right before close.
+	AntiSnipe *AntiSnipeRule `json:"anti_snipe,omitempty"`
+	// ClosesAtExtendedSeconds is how far AntiSnipe has pushed ClosesAt back so far.
+	ClosesAtExtendedSeconds int64 `json:"closes_at_extended_seconds,omitempty"`
+
+	// VoteResolution optionally lets players decide the outcome by voting once the prediction closes.
+	VoteResolution *VoteResolutionRule `json:"vote_resolution,omitempty"`
+	// VoteEscalated means the vote didn't reach a decision and an admin needs to decide instead.
+	VoteEscalated bool `json:"vote_escalated,omitempty"`
+
+	// PendingDecision is a decision on a high-stakes prediction, waiting for a second admin to confirm it.
+	PendingDecision *PendingDecision `json:"pending_decision,omitempty"`
+	// DecisionApprovals records the admins who requested and confirmed a high-stakes decision.
+	DecisionApprovals []DecisionApproval `json:"decision_approvals,omitempty"`
+	// RewardsHeld means achievements and coins for this decision are waiting for the dispute window to close.
+	RewardsHeld bool `json:"rewards_held,omitempty"`
+
+	// Commitment is the host's CommitmentHash of the answer, made public before anyone bets.
+	// Deciding the prediction needs the salt, which is published as CommitmentSalt so players can check the hash.
+	Commitment     string `json:"commitment,omitempty"`
+	CommitmentSalt string `json:"commitment_salt,omitempty"`
+
+	// HouseGame marks a round of a server-run game, decided by a provably fair roll at close.
+	HouseGame *HouseGameRound `json:"house_game,omitempty"`
+}
+
+// AntiSnipeRule extends a prediction's ClosesAt by ExtendSeconds whenever a bet lands within WindowSeconds of close,
+// up to MaxExtensionSeconds in total.
+type AntiSnipeRule struct {
+	WindowSeconds       int64 `json:"window_seconds"`
+	ExtendSeconds       int64 `json:"extend_seconds"`
+	MaxExtensionSeconds int64 `json:"max_extension_seconds"`
+}
+
+func (r AntiSnipeRule) Valid() bool {
+	return r.WindowSeconds > 0 && r.ExtendSeconds > 0 && r.MaxExtensionSeconds > 0
 }
 
 type PredictionChoice struct {
@@ -35,6 +100,23 @@ type PredictionOdds struct {
 	TotalTokensPlaced int64                  `json:"total_tokens_placed"`
 	TotalBetsPlaced   int                    `json:"total_bets_placed"`
 	Choices           []PredictionChoiceOdds `json:"choices"`
+
+	// Hidden is set when the odds have been redacted for this caller (see Prediction.HidesOddsBeforeBet)
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+// Redacted strips token totals, bet counts and odds, leaving only the choice IDs.
+func (o PredictionOdds) Redacted() PredictionOdds {
+	choices := make([]PredictionChoiceOdds, len(o.Choices))
+	for i := range o.Choices {
+		choices[i] = PredictionChoiceOdds{
+			PredictionChoiceID: o.Choices[i].PredictionChoiceID,
+		}
+	}
+	return PredictionOdds{
+		Choices: choices,
+		Hidden:  true,
+	}
 }
 
 type PredictionChoiceOdds struct {
@@ -47,6 +129,35 @@ type PredictionChoiceOdds struct {
 	OddsBasisPoints int64 `json:"odds_basis_points"`
 }
 
+// HidesOddsBeforeBet reports whether players who haven't bet yet should be kept from seeing the odds.
+// Once betting is over there is nothing left to protect, so odds are only hidden until the prediction closes.
+func (p Prediction) HidesOddsBeforeBet() bool {
+	if p.OddsVisibleBeforeBet {
+		return false
+	}
+	return p.beforeClose()
+}
+
+// OddsSealed reports whether the pool is sealed from everyone right now.
+func (p Prediction) OddsSealed() bool {
+	return p.Sealed && p.beforeClose()
+}
+
+// HasTag reports whether the prediction is tagged with tag (case-insensitive)
+func (p Prediction) HasTag(tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Prediction) beforeClose() bool {
+	return p.Status == PredictionStatusOpen || p.Status == PredictionStatusScheduled || p.Status == PredictionStatusPending
+}
+
 func (p Prediction) Odds(bets []Bet) PredictionOdds {
 	choicesMap := make(map[string]PredictionChoiceOdds, len(p.Choices))
 	for i := range p.Choices {
@@ -56,8 +167,16 @@ func (p Prediction) Odds(bets []Bet) PredictionOdds {
 	}
 
 	var totalTokensPlaced int64
+	totalBetsPlaced := 0
 	for _, bet := range bets {
+		if bet.Status == BetStatusVoided && p.Status != PredictionStatusVoid {
+			continue // its choice was removed and the bet refunded (a voided prediction still shows what its pool was)
+		}
+		if _, ok := choicesMap[bet.PredictionChoiceID]; !ok {
+			continue
+		}
 		totalTokensPlaced += bet.Amount
+		totalBetsPlaced++
 		choiceOdds := choicesMap[bet.PredictionChoiceID]
 		choiceOdds.TokensPlaced += bet.Amount
 		choiceOdds.BetsPlaced += 1
@@ -79,7 +198,7 @@ func (p Prediction) Odds(bets []Bet) PredictionOdds {
 
 	return PredictionOdds{
 		TotalTokensPlaced: totalTokensPlaced,
-		TotalBetsPlaced:   len(bets),
+		TotalBetsPlaced:   totalBetsPlaced,
 		Choices:           choices,
 	}
 }
@@ -109,3 +228,10 @@ type Bet struct {
 
 	WonAmount int64 `json:"won_amount"`
 }
+
+// Redacted strips the choice and amount of a bet on a sealed prediction.
+func (b Bet) Redacted() Bet {
+	b.PredictionChoiceID = ""
+	b.Amount = 0
+	return b
+}
@@ -0,0 +1,34 @@
+package types
+
+type ProposalStatus string
+
+const (
+	// ProposalStatusPending means the proposal is waiting for an admin. Only the proposer and admins can see it.
+	ProposalStatusPending = ProposalStatus("pending")
+	// ProposalStatusApproved means an admin turned the proposal into a prediction
+	ProposalStatusApproved = ProposalStatus("approved")
+	// ProposalStatusRejected means an admin turned the proposal down, see RejectionReason
+	ProposalStatusRejected = ProposalStatus("rejected")
+	// ProposalStatusMerged means the proposal duplicated an existing prediction
+	ProposalStatusMerged = ProposalStatus("merged")
+)
+
+// Proposal is a prediction suggested by a player, waiting for an admin to approve it
+type Proposal struct {
+	ID          string         `json:"id"`
+	CreatedAt   string         `json:"created_at"`
+	UserID      string         `json:"user_id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Choices     []string       `json:"choices"`
+	ClosesAt    string         `json:"closes_at"`
+	Tags        []string       `json:"tags"`
+	Status      ProposalStatus `json:"status"`
+
+	// PredictionID is the prediction the proposal was approved as or merged into
+	PredictionID    string `json:"prediction_id,omitempty"`
+	RejectionReason string `json:"rejection_reason,omitempty"`
+	// RewardCoins is how many coins the proposer got for the proposal being approved
+	RewardCoins int64  `json:"reward_coins,omitempty"`
+	ResolvedAt  string `json:"resolved_at,omitempty"`
+}
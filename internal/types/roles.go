@@ -0,0 +1,112 @@
+package types
+
+// Role gives a non-admin user some admin powers.
+type Role string
+
+const (
+	// RoleModerator can create, edit, close and reopen predictions, but not decide them
+	RoleModerator = Role("moderator")
+	// RoleBanker can gift tokens
+	RoleBanker = Role("banker")
+	// RoleHost can decide (and void) predictions, but only those in the occasions they host
+	RoleHost = Role("host")
+)
+
+var Roles = []Role{RoleModerator, RoleBanker, RoleHost}
+
+// Permission is something an admin endpoint needs. Admins have every permission.
+type Permission string
+
+const (
+	// PermissionManagePredictions covers creating, editing, closing and reopening predictions,
+	// along with templates, occasions and proposals
+	PermissionManagePredictions = Permission("manage_predictions")
+	// PermissionDecidePredictions covers deciding and voiding predictions, their oracles and disputes
+	PermissionDecidePredictions = Permission("decide_predictions")
+	// PermissionGiftTokens covers gifting tokens (and finding who to gift them to)
+	PermissionGiftTokens = Permission("gift_tokens")
+	// PermissionAdmin covers everything else under /api/admin/. No role grants it, so only admins have it.
+	PermissionAdmin = Permission("admin")
+)
+
+func (r Role) Valid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Role) Permissions() []Permission {
+	switch r {
+	case RoleModerator:
+		return []Permission{PermissionManagePredictions}
+	case RoleBanker:
+		return []Permission{PermissionGiftTokens}
+	case RoleHost:
+		return []Permission{PermissionDecidePredictions}
+	}
+	return nil
+}
+
+func (r Role) grants(permission Permission) bool {
+	for _, p := range r.Permissions() {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Staff returns true if the user is an admin or has any role.
+func (u User) Staff() bool {
+	return u.Admin || len(u.Roles) > 0
+}
+
+func (u User) Hosts(occasionID string) bool {
+	if occasionID == "" {
+		return false
+	}
+	for _, id := range u.HostOccasionIDs {
+		if id == occasionID {
+			return true
+		}
+	}
+	return false
+}
+
+// Can returns true if the user has the permission for a prediction in the given occasion.
+// Hosts only have their permissions for the occasions they host.
+func (u User) Can(permission Permission, occasionID string) bool {
+	if u.Admin {
+		return true
+	}
+	for _, role := range u.Roles {
+		if !role.grants(permission) {
+			continue
+		}
+		if role == RoleHost && !u.Hosts(occasionID) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// CanSomewhere returns true if the user has the permission for at least some predictions.
+func (u User) CanSomewhere(permission Permission) bool {
+	if u.Admin {
+		return true
+	}
+	for _, role := range u.Roles {
+		if !role.grants(permission) {
+			continue
+		}
+		if role == RoleHost && len(u.HostOccasionIDs) == 0 {
+			continue
+		}
+		return true
+	}
+	return false
+}
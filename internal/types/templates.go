@@ -0,0 +1,22 @@
+package types
+
+// PredictionTemplate is a saved prediction that can be instantiated again and again (ex: coin toss, Gatorade color)
+type PredictionTemplate struct {
+	ID          string `json:"id"`
+	CreatedAt   string `json:"created_at"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Choices are the names of the choices. Each instance gets freshly generated choice IDs.
+	Choices []string `json:"choices"`
+	// DurationSeconds is how long instances stay open for. 0 means instances don't close automatically.
+	DurationSeconds      int64 `json:"duration_seconds"`
+	OddsVisibleBeforeBet bool  `json:"odds_visible_before_bet"`
+
+	AntiSnipe          *AntiSnipeRule      `json:"anti_snipe,omitempty"`
+	VoteResolution     *VoteResolutionRule `json:"vote_resolution,omitempty"`
+	Sealed             bool                `json:"sealed"`
+	MinBet             int64               `json:"min_bet"`
+	MaxBet             int64               `json:"max_bet"`
+	MaxBankrollPercent int64               `json:"max_bankroll_percent"`
+	Tags               []string            `json:"tags"`
+}
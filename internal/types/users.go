@@ -21,6 +21,10 @@ type User struct {
 	// This isn't meant to be secure at all really - we expect the pin to simply be four digits, like 0000.
 	PINHash []byte `json:"pin_hash,omitempty"`
 	Admin   bool   `json:"admin"`
+	// Roles give some admin permissions without making the user an admin
+	Roles []Role `json:"roles,omitempty"`
+	// HostOccasionIDs are the occasions a RoleHost user hosts
+	HostOccasionIDs []string `json:"host_occasion_ids,omitempty"`
 
 	Tokens            int64 `json:"tokens"`
 	Spins             int64 `json:"spins"`
@@ -57,6 +61,14 @@ const (
 	TokenChangeCauseBetVoided = TokenChangeCause("bet-voided")
 	// TokenChangeCauseGift means these tokens were given as a gift by the hosts (probably because the user ran out of fake money :) )
 	TokenChangeCauseGift = TokenChangeCause("gift")
+	// TokenChangeCauseParlayPlaced means these tokens were taken as the user placed a parlay
+	TokenChangeCauseParlayPlaced = TokenChangeCause("parlay-placed")
+	// TokenChangeCauseParlayWon means these tokens were won after every leg of the user's parlay won
+	TokenChangeCauseParlayWon = TokenChangeCause("parlay-won")
+	// TokenChangeCauseParlayVoided means these tokens were refunded (or a payout reverted) because parlay legs were voided
+	TokenChangeCauseParlayVoided = TokenChangeCause("parlay-voided")
+	// TokenChangeCauseRedecided means a bet or parlay payout was taken back because its prediction was decided again after a dispute
+	TokenChangeCauseRedecided = TokenChangeCause("redecided")
 )
 
 type TokenLog struct {
@@ -69,4 +81,7 @@ type TokenLog struct {
 	// BetID and PredictionID are set if cause is TokenChangeCauseBetPlaced, TokenChangeCauseBetWon, or TokenChangeCauseBetVoided
 	BetID        string `json:"bet_id"`
 	PredictionID string `json:"prediction_id"`
+
+	// ParlayID is set if cause is TokenChangeCauseParlayPlaced, TokenChangeCauseParlayWon, or TokenChangeCauseParlayVoided
+	ParlayID string `json:"parlay_id,omitempty"`
 }
@@ -0,0 +1,58 @@
+package types
+
+type VoterPool string
+
+const (
+	// VoterPoolNonBettors lets only players without a bet on the prediction vote, so nobody decides their own payout
+	VoterPoolNonBettors = VoterPool("non_bettors")
+	// VoterPoolAll lets every player vote
+	VoterPoolAll = VoterPool("all")
+)
+
+// VoteResolutionRule lets players decide a closed prediction by voting on its outcome.
+// Once QuorumVotes votes are in and one choice has SupermajorityPercent of them, the prediction is decided.
+// If that doesn't happen before every eligible player has voted or the voting window ends, it's escalated to an admin.
+// Eligible players are those who have taken part in the market (bet, parlayed or voted), so dormant accounts don't stall it.
+type VoteResolutionRule struct {
+	Voters               VoterPool `json:"voters"`
+	QuorumVotes          int64     `json:"quorum_votes"`
+	SupermajorityPercent int64     `json:"supermajority_percent"`
+	// VotingWindowSeconds is how long after closing voting stays open. 0 means until every eligible player has voted.
+	VotingWindowSeconds int64 `json:"voting_window_seconds"`
+}
+
+func (r VoteResolutionRule) Valid() bool {
+	if r.Voters != VoterPoolNonBettors && r.Voters != VoterPoolAll {
+		return false
+	}
+	return r.QuorumVotes > 0 && r.SupermajorityPercent > 50 && r.SupermajorityPercent <= 100 && r.VotingWindowSeconds >= 0
+}
+
+// Winner returns the choice with a supermajority of the votes, or "" if there's no quorum or supermajority yet.
+// tally maps choice IDs to vote counts.
+func (r VoteResolutionRule) Winner(tally map[string]int64) string {
+	var total int64
+	for _, votes := range tally {
+		total += votes
+	}
+	if total == 0 || total < r.QuorumVotes {
+		return ""
+	}
+	for choiceID, votes := range tally {
+		if votes*100 >= total*r.SupermajorityPercent {
+			return choiceID
+		}
+	}
+	return ""
+}
+
+// ResolutionVote is a player's vote on the outcome of a vote-resolved prediction. Kept for auditing.
+type ResolutionVote struct {
+	ID           string `json:"id"`
+	CreatedAt    string `json:"created_at"`
+	PredictionID string `json:"prediction_id"`
+	UserID       string `json:"user_id"`
+	ChoiceID     string `json:"choice_id"`
+	// Superseded means the prediction was reopened after this vote, so it no longer counts
+	Superseded bool `json:"superseded,omitempty"`
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"io"
 	"io/fs"
 	"net/http"
 	"os"
@@ -24,14 +25,82 @@ var ui embed.FS
 
 var logger = logrus.New()
 
+type AdminConfig struct {
+	Name string `json:"name"`
+	PIN  string `json:"pin"`
+}
+
 type Config struct {
 	Debug    bool   `json:"debug"`
 	AdminPIN string `json:"admin_pin"`
+	// Admins are extra admin accounts, created at startup if they don't exist yet.
+	// Existing users with these names are made admins (their PIN is left alone).
+	Admins []AdminConfig `json:"admins"`
 
 	RepoPath string `json:"repo_path"`
 
 	StartingTokens int64 `json:"starting_tokens"`
 	StartingCoins  int64 `json:"starting_coins"`
+
+	// ProposalRewardCoins are given to players whose proposed predictions get approved
+	ProposalRewardCoins int64 `json:"proposal_reward_coins"`
+	// DecisionConfirmationThreshold is the pool size above which deciding or voiding a prediction needs a second admin. 0 disables it.
+	DecisionConfirmationThreshold int64 `json:"decision_confirmation_threshold"`
+	// DisputeWindowMinutes is how long after a decision bettors can dispute it. 0 disables disputes.
+	DisputeWindowMinutes int64 `json:"dispute_window_minutes"`
+
+	// SweepIntervalSeconds is how often predictions are checked for opening/closing. Defaults to 5.
+	SweepIntervalSeconds int64 `json:"sweep_interval_seconds"`
+	// ClosingSoonOffsetsSeconds are how long before closing to warn users who haven't bet. Defaults to 5 minutes and 1 minute.
+	ClosingSoonOffsetsSeconds []int64 `json:"closing_soon_offsets_seconds"`
+
+	// ArchivePath is where archived predictions are stored. Defaults to repo_path + ".archive.gz".
+	ArchivePath string `json:"archive_path"`
+	// ArchiveAfterHours automatically archives predictions this long after they were decided or voided. 0 disables automatic archiving.
+	ArchiveAfterHours int64 `json:"archive_after_hours"`
+
+	// HouseGames are server-run games (coin_flip, dice, roulette) that always have a round going. Empty disables them.
+	HouseGames []types.HouseGameKind `json:"house_games"`
+	// HouseGameIntervalSeconds is how often a new round of each house game starts. Defaults to 600.
+	HouseGameIntervalSeconds int64 `json:"house_game_interval_seconds"`
+	// HouseGameBetWindowSeconds is how long each round takes bets before it's rolled. Defaults to 120.
+	HouseGameBetWindowSeconds int64 `json:"house_game_bet_window_seconds"`
+}
+
+// writeAtomically writes to path + ".new", moves any existing file to path + ".old", then moves the new file into place.
+func writeAtomically(path string, write func(w io.Writer) error) bool {
+	newPath := path + ".new"
+	oldPath := path + ".old"
+
+	handle, err := os.Create(newPath)
+	if err != nil {
+		logger.WithError(err).WithField("path", path).Warn("failed to create persistence path!")
+		return false
+	}
+	defer handle.Close()
+
+	err1 := write(handle)
+	if err1 != nil {
+		logger.WithError(err1).WithField("path", path).Warn("failed to save to handle")
+	}
+	err2 := handle.Close()
+	if err2 != nil {
+		logger.WithError(err2).WithField("path", path).Warn("failed to close handle")
+	}
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	err = os.Rename(path, oldPath)
+	if err != nil && !os.IsNotExist(err) {
+		logger.WithError(err).WithField("path", path).Warn("failed to backup data to .old, ignoring")
+	}
+	err = os.Rename(newPath, path)
+	if err != nil {
+		logger.WithError(err).WithField("path", path).Warn("failed to move .new data to path!")
+		return false
+	}
+	return true
 }
 
 func main() {
@@ -67,6 +136,27 @@ func main() {
 		logger.SetLevel(logrus.DebugLevel)
 	}
 
+	if config.SweepIntervalSeconds <= 0 {
+		config.SweepIntervalSeconds = 5
+	}
+	if config.ClosingSoonOffsetsSeconds == nil {
+		config.ClosingSoonOffsetsSeconds = []int64{5 * 60, 60}
+	}
+	if config.HouseGameIntervalSeconds <= 0 {
+		config.HouseGameIntervalSeconds = 600
+	}
+	if config.HouseGameBetWindowSeconds <= 0 {
+		config.HouseGameBetWindowSeconds = 120
+	}
+	for _, kind := range config.HouseGames {
+		if !kind.Valid() {
+			logger.WithField("house_game", kind).Fatal("unknown house game, use coin_flip, dice or roulette")
+		}
+	}
+	if config.ArchivePath == "" && config.RepoPath != "" {
+		config.ArchivePath = config.RepoPath + ".archive.gz"
+	}
+
 	store := repo.NewStore()
 
 	(func() {
@@ -117,46 +207,86 @@ func main() {
 		logger.Info("added admin user")
 	})()
 
-	// Create and start event hub for SSE
-	eventHub := events.NewHub()
-	go eventHub.Run()
+	for _, admin := range config.Admins {
+		if admin.Name == "" {
+			logger.Fatal("admins must have a name")
+		}
 
-	// save repo data every minute if dirty
-	save := func() {
-		if !store.IsDirty() {
-			return
+		existing, err := store.GetUserByName(admin.Name)
+		if err == nil {
+			if !existing.Admin {
+				if err := store.SetUserAdmin(existing.ID, true); err != nil {
+					logger.WithError(err).Fatal("failed to make user admin")
+				}
+				logger.WithField("name", admin.Name).Info("made user admin")
+			}
+			continue
+		}
+		if err != repo.ErrUserNotFound {
+			logger.WithError(err).Fatal("failed to look up admin user")
 		}
 
-		newPath := config.RepoPath + ".new"
-		oldPath := config.RepoPath + ".old"
+		if admin.PIN == "" {
+			logger.WithField("name", admin.Name).Fatal("new admins must have a pin")
+		}
 
-		handle, err := os.Create(newPath)
+		adminID, err := repo.NewID()
 		if err != nil {
-			logger.WithError(err).Warn("failed to create persistence path!")
-			return
+			logger.WithError(err).Fatal("failed to generate admin ID")
+		}
+
+		pinHash, err := bcrypt.GenerateFromPassword([]byte(admin.PIN), bcrypt.MinCost)
+		if err != nil {
+			logger.WithError(err).Fatal("failed to generate admin bcrypt hash")
 		}
-		defer handle.Close()
 
-		err1 := store.Save(handle)
-		if err1 != nil {
-			logger.WithError(err1).Warn("failed to save store to handle")
+		err = store.AddUser(types.User{
+			ID:      adminID,
+			Name:    admin.Name,
+			PINHash: pinHash,
+			Admin:   true,
+			Tokens:  0,
+		}, 0)
+		if err != nil {
+			logger.WithError(err).Fatal("failed to add admin user")
 		}
-		err2 := handle.Close()
-		if err2 != nil {
-			logger.WithError(err2).Warn("failed to close handle")
+		logger.WithField("name", admin.Name).Info("added admin user")
+	}
+
+	if config.ArchivePath != "" {
+		handle, err := os.Open(config.ArchivePath)
+		if err == nil {
+			err = store.LoadArchive(handle)
+			handle.Close()
+			if err != nil {
+				logger.WithError(err).Fatal("failed to load archive from path")
+			}
+			logger.Info("loaded archive")
+		} else if !os.IsNotExist(err) {
+			logger.WithError(err).Fatal("unhandled error while opening archive path")
 		}
-		if err1 != nil || err2 != nil {
-			return
+	}
+
+	// Create and start event hub for SSE
+	eventHub := events.NewHub()
+	go eventHub.Run()
+
+	// save repo data every minute if dirty
+	save := func() {
+		// the archive is written first: archived predictions are removed from the main store,
+		// so saving the store before its archive could lose them.
+		if config.ArchivePath != "" && store.IsArchiveDirty() {
+			if !writeAtomically(config.ArchivePath, store.SaveArchive) {
+				return
+			}
+			logger.Info("saved archive")
 		}
 
-		err = os.Rename(config.RepoPath, oldPath)
-		if err != nil && !os.IsNotExist(err) {
-			logger.WithError(err).Warn("failed to backup repo data to .old, ignoring")
+		if !store.IsDirty() {
+			return
 		}
-		err = os.Rename(newPath, config.RepoPath)
-		if err != nil {
-			logger.WithError(err).Warn("failed to move .new repo data to path!")
-		} else {
+
+		if writeAtomically(config.RepoPath, store.Save) {
 			logger.Info("saved state")
 		}
 	}
@@ -181,26 +311,75 @@ func main() {
 		gracefulCancel()
 	}()
 
+	closingSoonOffsets := make([]time.Duration, 0, len(config.ClosingSoonOffsetsSeconds))
+	for _, seconds := range config.ClosingSoonOffsetsSeconds {
+		if seconds > 0 {
+			closingSoonOffsets = append(closingSoonOffsets, time.Duration(seconds)*time.Second)
+		}
+	}
+
 	h := &handlers.Handler{
-		GracefulCtx:    gracefulCtx,
-		Store:          store,
-		Logger:         logger,
-		StartingTokens: config.StartingTokens,
-		StartingCoins:  config.StartingCoins,
-		EventHub:       eventHub,
+		GracefulCtx:                   gracefulCtx,
+		Store:                         store,
+		Logger:                        logger,
+		StartingTokens:                config.StartingTokens,
+		StartingCoins:                 config.StartingCoins,
+		EventHub:                      eventHub,
+		ClosingSoonOffsets:            closingSoonOffsets,
+		ProposalRewardCoins:           config.ProposalRewardCoins,
+		DecisionConfirmationThreshold: config.DecisionConfirmationThreshold,
+		DisputeWindow:                 time.Duration(config.DisputeWindowMinutes) * time.Minute,
+		HouseGames:                    config.HouseGames,
+		HouseGameInterval:             time.Duration(config.HouseGameIntervalSeconds) * time.Second,
+		HouseGameBetWindow:            time.Duration(config.HouseGameBetWindowSeconds) * time.Second,
 	}
 
-	// Sweep expired predictions every minute
+	// Sweep expired predictions every few seconds
 	go func() {
 		// Run once at startup
 		h.Sweep()
-		ticker := time.NewTicker(time.Minute)
+		ticker := time.NewTicker(time.Duration(config.SweepIntervalSeconds) * time.Second)
 		for {
 			<-ticker.C
 			h.Sweep()
 		}
 	}()
 
+	// Poll oracle endpoints on their own goroutine so a slow endpoint doesn't hold up the sweep.
+	// Each oracle is only fetched once its own interval has passed.
+	go func() {
+		h.PollOracles()
+		ticker := time.NewTicker(time.Duration(config.SweepIntervalSeconds) * time.Second)
+		for {
+			<-ticker.C
+			h.PollOracles()
+		}
+	}()
+
+	// Archive old finished predictions every hour
+	if config.ArchiveAfterHours > 0 {
+		go func() {
+			archive := func() {
+				archived, err := store.ArchivePredictions(nil, time.Now().Add(-time.Duration(config.ArchiveAfterHours)*time.Hour))
+				if err != nil {
+					logger.WithError(err).Warn("failed to archive predictions")
+					return
+				}
+				if archived > 0 {
+					logger.WithField("archived", archived).Info("archived predictions")
+					eventHub.EmitPredictions()
+					eventHub.EmitBetsAll()
+				}
+			}
+			archive()
+			ticker := time.NewTicker(time.Hour)
+			for {
+				<-ticker.C
+				archive()
+			}
+		}()
+	}
+
 	mux := http.NewServeMux()
 	h.RegisterRoutes(mux)
 